@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/renniemaharaj/news/internal/config"
+	"github.com/renniemaharaj/news/internal/coordinator"
+	"github.com/renniemaharaj/news/internal/reports"
+
+	"github.com/renniemaharaj/news/pkg/pool"
+)
+
+// A subcommand receives the config path and its own arguments
+type command struct {
+	usage string
+	run   func(configPath string, args []string) error
+}
+
+var commands = map[string]command{
+	"serve":           {"serve", runServe},
+	"scrape":          {"scrape [--channel name] [--keyword query]... [--dry-run]", runScrape},
+	"list":            {"list [--channel name] [--archived] [--max n] [--relevance n]", runList},
+	"search":          {"search [--channel name] [--archived] [--max n] [--relevance n] <query>", runList},
+	"debug":           {"debug [--channel name] <url|keyword>", runDebug},
+	"show":            {"show [--channel name] [--provenance] <id>", runShow},
+	"prune":           {"prune [--channel name]", runPrune},
+	"export":          {"export [--channel name] [--out file]", runExport},
+	"import":          {"import [--channel name] <file|->", runImport},
+	"validate-config": {"validate-config", runValidateConfig},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: news [--config file] <command> [flags]\n\ncommands:\n")
+	for _, name := range []string{"serve", "scrape", "debug", "list", "search", "show", "prune", "export", "import", "validate-config"} {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+}
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ", ") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// Opens the config and resolves the named channel
+func openChannel(configPath, name string) (*config.Live, config.Channel, error) {
+	live, err := config.Open(configPath)
+	if err != nil {
+		return nil, config.Channel{}, err
+	}
+
+	channel, ok := live.Get().Config.Channel(name)
+	if !ok {
+		return nil, config.Channel{}, fmt.Errorf("unknown channel %q", name)
+	}
+	return live, channel, nil
+}
+
+func runScrape(configPath string, args []string) error {
+	fs := flag.NewFlagSet("scrape", flag.ExitOnError)
+	name := fs.String("channel", config.DefaultChannel, "channel to scrape")
+	dryRun := fs.Bool("dry-run", false, "print reports instead of saving them")
+	var queries stringList
+	fs.Var(&queries, "keyword", "search query to use instead of the channel's keywords (repeatable)")
+	fs.Parse(args)
+
+	live, _, err := openChannel(configPath, *name)
+	if err != nil {
+		return err
+	}
+	snap := live.Get()
+	configureLogging(snap.Config.Logging)
+
+	keyPool := &pool.Instance{EnvVar: snap.Config.KeysEnv}
+	keyPool.InitializePool()
+
+	var keywords []config.Keyword
+	for _, query := range queries {
+		keywords = append(keywords, config.Keyword{Query: query})
+	}
+
+	produced, err := reports.Scrape(keyPool, snap, *name, reports.ScrapeOptions{Keywords: keywords, DryRun: *dryRun})
+	if *dryRun {
+		printJSON(produced)
+	}
+	return err
+}
+
+func runDebug(configPath string, args []string) error {
+	fs := flag.NewFlagSet("debug", flag.ExitOnError)
+	name := fs.String("channel", config.DefaultChannel, "channel whose settings and instructions to use")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("debug takes one URL or keyword")
+	}
+
+	live, channel, err := openChannel(configPath, *name)
+	if err != nil {
+		return err
+	}
+	snap := live.Get()
+	configureLogging(snap.Config.Logging)
+
+	keyPool := &pool.Instance{EnvVar: snap.Config.KeysEnv}
+	keyPool.InitializePool()
+
+	_, err = coordinator.Debug(snap.Config, channel, keyPool, snap.Instructions, strings.Join(fs.Args(), " "), os.Stdout)
+	return err
+}
+
+func runList(configPath string, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	name := fs.String("channel", config.DefaultChannel, "channel to read")
+	max := fs.Int("max", 0, "maximum number of reports, 0 for all")
+	relevance := fs.Int("relevance", 0, "only reports with relevance above this")
+	archived := fs.Bool("archived", false, "read the archive of expired reports instead")
+	fs.Parse(args)
+
+	_, channel, err := openChannel(configPath, *name)
+	if err != nil {
+		return err
+	}
+
+	found, err := reports.List(channel.Dir, *archived, strings.Join(fs.Args(), " "), 0, *max, *relevance)
+	if err != nil {
+		return err
+	}
+
+	for _, report := range found {
+		fmt.Printf("%s\t%s\t%d\t%s\n", reports.ID(report), report.Date, report.Relevance, report.Title)
+	}
+	return nil
+}
+
+func runShow(configPath string, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	name := fs.String("channel", config.DefaultChannel, "channel to read")
+	withProvenance := fs.Bool("provenance", false, "print the report's provenance record instead")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("show takes exactly one report id")
+	}
+
+	_, channel, err := openChannel(configPath, *name)
+	if err != nil {
+		return err
+	}
+
+	if *withProvenance {
+		prov, err := reports.FindProvenance(channel.Dir, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		printJSON(prov)
+		return nil
+	}
+
+	report, err := reports.Find(channel.Dir, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	printJSON(report)
+	return nil
+}
+
+func runPrune(configPath string, args []string) error {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	name := fs.String("channel", "", "channel to prune, all channels when empty")
+	fs.Parse(args)
+
+	live, err := config.Open(configPath)
+	if err != nil {
+		return err
+	}
+	cfg := live.Get().Config
+
+	names := cfg.ChannelNames()
+	if *name != "" {
+		names = []string{*name}
+	}
+
+	for _, n := range names {
+		channel, ok := cfg.Channel(n)
+		if !ok {
+			return fmt.Errorf("unknown channel %q", n)
+		}
+		archived, deleted := reports.Prune(channel.Dir, channel)
+		fmt.Printf("%s: archived %d reports, deleted %d\n", n, archived, deleted)
+	}
+	return nil
+}
+
+func runExport(configPath string, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	name := fs.String("channel", config.DefaultChannel, "channel to export")
+	out := fs.String("out", "", "file to write, stdout when empty")
+	fs.Parse(args)
+
+	_, channel, err := openChannel(configPath, *name)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	n, err := reports.Export(channel.Dir, w)
+	if err != nil {
+		return err
+	}
+	log.Printf("📦 Exported %d reports", n)
+	return nil
+}
+
+func runImport(configPath string, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	name := fs.String("channel", config.DefaultChannel, "channel to import into")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("import takes exactly one file, or - for stdin")
+	}
+
+	_, channel, err := openChannel(configPath, *name)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	n, err := reports.Import(channel.Dir, r)
+	log.Printf("📥 Imported %d reports", n)
+	return err
+}
+
+func runValidateConfig(configPath string, args []string) error {
+	live, err := config.Open(configPath)
+	if err != nil {
+		return err
+	}
+
+	cfg := live.Get().Config
+	fmt.Printf("✅ %s is valid: channels %s\n", configPath, strings.Join(cfg.ChannelNames(), ", "))
+	return nil
+}
+
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
@@ -1,15 +1,28 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/renniemaharaj/news/internal/config"
 	"github.com/renniemaharaj/news/internal/reports"
+
+	"github.com/renniemaharaj/news/pkg/logging"
+	"github.com/renniemaharaj/news/pkg/pool"
 )
 
+// How often config.json and instruction files are checked for changes
+const configPollInterval = 5 * time.Second
+
+// How often report stores are checked for external edits
+const storePollInterval = 10 * time.Second
+
 func startHealthPulse(apiURL string) {
 
 	go func() {
@@ -35,36 +48,107 @@ func startHealthPulse(apiURL string) {
 	}()
 }
 
-func scrapeOnEmptyDir() {
-	count := reports.CountReports()
-	if count <= 0 {
-		reports.ScrapeReports()
+// Scrapes every channel that has no reports yet
+func scrapeOnEmptyDir(p *pool.Instance, live *config.Live) {
+	cfg := live.Get().Config
+	for _, name := range cfg.ChannelNames() {
+		channel, _ := cfg.Channel(name)
+		if reports.CountReports(channel.Dir) <= 0 {
+			reports.ScrapeReports(p, live, name)
+		}
+	}
+}
+
+// Reloads the key pool and config whenever the process gets SIGHUP
+func reloadOnHangup(p *pool.Instance, live *config.Live) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	go func() {
+		for range hangup {
+			if err := p.Reload(); err != nil {
+				log.Printf("⚠️ Failed to reload API keys: %v", err)
+			}
+			live.Reload()
+		}
+	}()
+}
+
+// Applies log truncation and opt-in prompt logging
+func configureLogging(settings config.Logging) {
+	logging.SetPayloadLimit(settings.PayloadLimit)
+	if err := logging.SetPromptLog(settings.PromptLogFile); err != nil {
+		log.Printf("⚠️ Failed to open prompt log: %v", err)
 	}
 }
 
 func main() {
+	configPath := flag.String("config", "config.json", "path to the config file")
+	flag.Usage = usage
+	flag.Parse()
+
+	// Without a subcommand the server starts, as it always has
+	name, args := "serve", flag.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(*configPath, args); err != nil {
+		log.Fatalf("❌ %s: %v", name, err)
+	}
+}
+
+// Starts the scheduler and the HTTP API
+func runServe(configPath string, args []string) error {
+	// Config and instruction templates are parsed once, then hot reloaded
+	live, err := config.Open(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	go live.Watch(configPollInterval)
+	go reports.WatchStores(live, storePollInterval)
+
+	cfg := live.Get().Config
+	configureLogging(cfg.Logging)
+
+	// One key pool shared by every pipeline run
+	keyPool := &pool.Instance{EnvVar: cfg.KeysEnv}
+	keyPool.InitializePool()
+	reloadOnHangup(keyPool, live)
+
 	// Start the report scraping scheduler
-	go reports.DailyScheduler()
+	go reports.DailyScheduler(keyPool, live)
 
-	// Count reports
-	reports.CountReports()
+	// Normalize dates stored before they were normalized on save
+	for _, name := range cfg.ChannelNames() {
+		channel, _ := cfg.Channel(name)
+		if n := reports.MigrateDates(channel.Dir); n > 0 {
+			log.Printf("🗓️ Migrated %d report dates in channel %s", n, name)
+		}
+	}
 
 	// scrape on empty dir
-	scrapeOnEmptyDir()
+	scrapeOnEmptyDir(keyPool, live)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "4000"
-	}
+	port := cfg.Server.Port
 
-	// Setup CORS-wrapped handlers
-	handler := reports.CORSMiddleware(http.HandlerFunc(reports.HandleReportRequests))
-	http.Handle("/reports", handler)
-	http.Handle("/healthcheck", reports.HealthHandler("v1"))
+	// Compressed API handlers, every route behind the CORS policy
+	mux := http.NewServeMux()
+	mux.Handle("/reports", reports.GzipMiddleware(reports.HandleReportRequests(live)))
+	mux.Handle("/channels/{name}/reports", reports.GzipMiddleware(reports.HandleChannelReports(live)))
+	mux.Handle("/reports/{id}/provenance", reports.GzipMiddleware(reports.HandleProvenance(live)))
+	mux.Handle("/channels/{name}/reports/{id}/provenance", reports.GzipMiddleware(reports.HandleProvenance(live)))
+	mux.Handle("/healthcheck", reports.HealthHandler("v1", keyPool))
+	mux.Handle("/admin/pool/reload", reports.PoolReloadHandler(keyPool, live))
 
 	// Start health pulse
-	startHealthPulse(os.Getenv("STAY_ALIVE_API_URL"))
+	startHealthPulse(cfg.Server.StayAliveURL)
 
 	log.Printf("🟢 API running at http://localhost:%s", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	return http.ListenAndServe(":"+port, reports.CORSMiddleware(live, mux))
 }
@@ -1,14 +1,16 @@
 package browser
 
 import (
-	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"golang.org/x/net/html"
+
+	"github.com/renniemaharaj/news/internal/types"
 )
 
 func resolveURL(link string, base string) string {
@@ -33,20 +35,23 @@ func isLikelyThumbnail(src string) bool {
 		(strings.HasSuffix(lower, ".jpg") || strings.HasSuffix(lower, ".jpeg") || strings.HasSuffix(lower, ".png") || strings.HasSuffix(lower, ".webp"))
 }
 
-// Browser scraping method, returns string of all textContent + images
-func Scrape(url string) (string, error) {
+// Browser scraping method, returns the page's textContent + images
+func Scrape(url string) (types.Page, error) {
 	log.Printf("🗃️ Visiting site for scraping: %s", url)
-	req, _ := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return types.Page{}, err
+	}
 	req.Header.Set("User-Agent", "Mozilla/5.0")
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", err
+		return types.Page{}, err
 	}
 	defer resp.Body.Close()
 
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
-		return "", err
+		return types.Page{}, err
 	}
 
 	var sb strings.Builder
@@ -68,16 +73,7 @@ func Scrape(url string) (string, error) {
 		}
 	})
 
-	// Append the images at the bottom
-	if len(images) > 0 {
-		sb.WriteString("\n\nimages:\n")
-		for _, img := range images {
-			sb.WriteString(img + "\n")
-		}
-	}
-
-	sb.WriteString(fmt.Sprintf("\n\nsource_url=%s", url))
-	return sb.String(), nil
+	return types.Page{URL: url, Text: sb.String(), Images: images, FetchedAt: time.Now().UTC()}, nil
 }
 
 var skipDomains = map[string]struct{}{
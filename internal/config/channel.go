@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultChannel is the channel built from the top-level keywords, served at /reports
+const DefaultChannel = "default"
+
+// Channel names become URL segments and directory names
+var channelName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Channel is a separately scheduled feed with its own keywords, instruction
+// profile, retention and report namespace. Unset fields inherit the top-level
+// settings.
+type Channel struct {
+	Keywords         []Keyword `json:"keywords"`
+	Profile          string    `json:"profile"` // instruction profile for keywords that name none
+	NumSitesPerQuery int       `json:"num_sites_per_query"`
+	Dir              string    `json:"dir"` // defaults to <reports.dir>/<name>
+	Expiration       Duration  `json:"expiration"`
+	ReportingHour    *int      `json:"reporting_hour"`
+
+	TagExpiration map[string]Duration `json:"tag_expiration"`
+	DeleteAfter   Duration            `json:"delete_after"`
+}
+
+// Channel returns the named channel with inherited settings filled in
+func (c *Config) Channel(name string) (Channel, bool) {
+	if name == DefaultChannel {
+		if len(c.Keywords) == 0 {
+			return Channel{}, false
+		}
+		hour := c.Reports.ReportingHour
+		return Channel{
+			Keywords:         c.Keywords,
+			NumSitesPerQuery: c.NumSitesPerQuery,
+			Dir:              c.Reports.Dir,
+			Expiration:       c.Reports.Expiration,
+			ReportingHour:    &hour,
+			TagExpiration:    c.Reports.TagExpiration,
+			DeleteAfter:      c.Reports.DeleteAfter,
+		}, true
+	}
+
+	ch, ok := c.Channels[name]
+	if !ok {
+		return Channel{}, false
+	}
+	if ch.NumSitesPerQuery == 0 {
+		ch.NumSitesPerQuery = c.NumSitesPerQuery
+	}
+	if ch.Dir == "" {
+		ch.Dir = filepath.Join(c.Reports.Dir, name)
+	}
+	if ch.Expiration.Duration == 0 {
+		ch.Expiration = c.Reports.Expiration
+	}
+	if ch.ReportingHour == nil {
+		hour := c.Reports.ReportingHour
+		ch.ReportingHour = &hour
+	}
+	if ch.TagExpiration == nil {
+		ch.TagExpiration = c.Reports.TagExpiration
+	}
+	if ch.DeleteAfter.Duration == 0 {
+		ch.DeleteAfter = c.Reports.DeleteAfter
+	}
+	return ch, true
+}
+
+// ExpirationFor returns how long a report with tags stays out of the archive
+func (ch Channel) ExpirationFor(tags []string) time.Duration {
+	expiration := ch.Expiration.Duration
+	matched := false
+	for _, tag := range tags {
+		for name, d := range ch.TagExpiration {
+			if !strings.EqualFold(name, tag) {
+				continue
+			}
+			if !matched || d.Duration > expiration {
+				expiration = d.Duration
+			}
+			matched = true
+		}
+	}
+	return expiration
+}
+
+// ChannelNames lists every configured channel, the default one first
+func (c *Config) ChannelNames() []string {
+	var names []string
+	if len(c.Keywords) > 0 {
+		names = append(names, DefaultChannel)
+	}
+
+	named := make([]string, 0, len(c.Channels))
+	for name := range c.Channels {
+		named = append(named, name)
+	}
+	sort.Strings(named)
+	return append(names, named...)
+}
+
+// Returns one message per invalid channel field
+func (c *Config) validateChannels() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if len(c.ChannelNames()) == 0 {
+		add("keywords: at least one keyword or channel is required")
+	}
+
+	for name, ch := range c.Channels {
+		path := "channels." + name
+		if name == DefaultChannel {
+			add("%s: %q is reserved for the top-level keywords", path, DefaultChannel)
+		} else if !channelName.MatchString(name) {
+			add("%s: name must be lowercase letters, digits and dashes", path)
+		}
+
+		if len(ch.Keywords) == 0 {
+			add("%s.keywords: at least one keyword is required", path)
+		}
+		for i, keyword := range ch.Keywords {
+			if strings.TrimSpace(keyword.Query) == "" {
+				add("%s.keywords[%d].query: must not be empty", path, i)
+			}
+			if _, ok := c.Instructions.Profiles[keyword.Profile]; keyword.Profile != "" && !ok {
+				add("%s.keywords[%d].profile: undefined instruction profile %q", path, i, keyword.Profile)
+			}
+		}
+		if _, ok := c.Instructions.Profiles[ch.Profile]; ch.Profile != "" && !ok {
+			add("%s.profile: undefined instruction profile %q", path, ch.Profile)
+		}
+		if ch.NumSitesPerQuery < 0 {
+			add("%s.num_sites_per_query: must not be negative, got %d", path, ch.NumSitesPerQuery)
+		}
+		if ch.Expiration.Duration < 0 {
+			add("%s.expiration: must not be negative, got %s", path, ch.Expiration)
+		}
+		if ch.ReportingHour != nil && (*ch.ReportingHour < 0 || *ch.ReportingHour > 23) {
+			add("%s.reporting_hour: must be between 0 and 23, got %d", path, *ch.ReportingHour)
+		}
+		// Inherited retention is already checked at the top level
+		overrides := ch.Expiration.Duration > 0 || ch.TagExpiration != nil || ch.DeleteAfter.Duration != 0
+		if resolved, _ := c.Channel(name); overrides {
+			problems = append(problems, validateRetention(path, resolved.Expiration, resolved.TagExpiration, resolved.DeleteAfter)...)
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// Checks that reports expire before they are deleted for good
+func validateRetention(path string, expiration Duration, tags map[string]Duration, deleteAfter Duration) []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if deleteAfter.Duration < 0 {
+		add("%s.delete_after: must not be negative, got %s", path, deleteAfter)
+	}
+	keep := deleteAfter.Duration > 0
+	if keep && expiration.Duration >= deleteAfter.Duration {
+		add("%s.delete_after: must be longer than expiration %s, got %s", path, expiration, deleteAfter)
+	}
+	for tag, d := range tags {
+		if d.Duration <= 0 {
+			add("%s.tag_expiration.%s: must be positive, got %s", path, tag, d)
+		} else if keep && d.Duration >= deleteAfter.Duration {
+			add("%s.tag_expiration.%s: must be shorter than delete_after %s, got %s", path, tag, deleteAfter, d)
+		}
+	}
+	return problems
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpirationFor(t *testing.T) {
+	ch := Channel{
+		Expiration: Duration{72 * time.Hour},
+		TagExpiration: map[string]Duration{
+			"breaking": {6 * time.Hour},
+			"feature":  {240 * time.Hour},
+			"Politics": {24 * time.Hour},
+		},
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+		want time.Duration
+	}{
+		{"untagged", nil, 72 * time.Hour},
+		{"unknown tag", []string{"sports"}, 72 * time.Hour},
+		{"shorter tag", []string{"breaking"}, 6 * time.Hour},
+		{"longer tag", []string{"feature"}, 240 * time.Hour},
+		{"case insensitive", []string{"politics"}, 24 * time.Hour},
+		{"longest tag wins", []string{"breaking", "politics"}, 24 * time.Hour},
+		{"longest tag wins in any order", []string{"feature", "breaking"}, 240 * time.Hour},
+		{"unknown tags ignored", []string{"sports", "breaking"}, 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ch.ExpirationFor(tt.tags); got != tt.want {
+				t.Errorf("ExpirationFor(%v) = %v, want %v", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRetention(t *testing.T) {
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name        string
+		expiration  time.Duration
+		tags        map[string]Duration
+		deleteAfter time.Duration
+		want        []string // substrings of the expected problems, none when empty
+	}{
+		{"valid", 3 * day, map[string]Duration{"breaking": {day}}, 30 * day, nil},
+		{"never deleted", 3 * day, map[string]Duration{"feature": {90 * day}}, 0, nil},
+		{"negative delete_after", 3 * day, nil, -day, []string{"delete_after: must not be negative"}},
+		{"delete before expiration", 3 * day, nil, 2 * day, []string{"delete_after: must be longer than expiration"}},
+		{"delete at expiration", 3 * day, nil, 3 * day, []string{"delete_after: must be longer than expiration"}},
+		{"non-positive tag", 3 * day, map[string]Duration{"breaking": {0}}, 30 * day, []string{"tag_expiration.breaking: must be positive"}},
+		{"tag outlives delete_after", 3 * day, map[string]Duration{"feature": {30 * day}}, 30 * day, []string{"tag_expiration.feature: must be shorter than delete_after"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateRetention("reports", Duration{tt.expiration}, tt.tags, Duration{tt.deleteAfter})
+			if len(problems) != len(tt.want) {
+				t.Fatalf("problems %q, want %d", problems, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(problems[i], want) {
+					t.Errorf("problem %q, want %q", problems[i], want)
+				}
+			}
+		})
+	}
+}
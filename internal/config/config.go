@@ -2,21 +2,336 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/renniemaharaj/news/pkg/transformer"
 )
 
 type Config struct {
-	Keywords         []string `json:"keywords"`
-	NumSitesPerQuery int      `json:"num_sites_per_query"`
+	Keywords         []Keyword `json:"keywords"`
+	NumSitesPerQuery int       `json:"num_sites_per_query"`
+	Repair           bool      `json:"repair"`
+	PageTokenBudget  int       `json:"page_token_budget"`
+	CallTokenBudget  int       `json:"call_token_budget"`
+	Models           []string  `json:"models"` // ordered model fallback chain
+
+	// Per-model and per-stage overrides of the built-in model parameters
+	Parameters transformer.Tuning `json:"parameters"`
+
+	// Named instruction profiles selected per keyword
+	Instructions transformer.InstructionConfig `json:"instructions"`
+
+	// Additional feeds, each served at /channels/{name}/reports
+	Channels map[string]Channel `json:"channels"`
+
+	Server  Server  `json:"server"`
+	Reports Reports `json:"reports"`
+	Cache   Cache   `json:"cache"`
+	Logging Logging `json:"logging"`
+
+	// Environment variable holding the JSON array of API keys. The keys
+	// themselves never live in config.json.
+	KeysEnv string `json:"keys_env"`
 }
 
-// Idiomatic load function for config
+// Server configures the HTTP API
+type Server struct {
+	Port         string   `json:"port"`
+	CORS         CORS     `json:"cors"`
+	CORSOrigins  []string `json:"cors_origins"` // deprecated, appended to cors.origins
+	StayAliveURL string   `json:"stay_alive_url"`
+	AdminToken   string   `json:"-"` // environment only
+
+	// How long clients may reuse a report list before revalidating it
+	CacheMaxAge Duration `json:"cache_max_age"`
+}
+
+// Reports configures the report store and its schedule
+type Reports struct {
+	Dir           string   `json:"dir"`
+	Expiration    Duration `json:"expiration"` // age at which reports move to the archive
+	ReportingHour int      `json:"reporting_hour"`
+
+	// Per-tag expiration overrides, the longest matching tag wins
+	TagExpiration map[string]Duration `json:"tag_expiration"`
+	// Age at which archived reports are deleted for good, 0 keeps them forever
+	DeleteAfter Duration `json:"delete_after"`
+}
+
+// Cache configures the model response cache
+type Cache struct {
+	Dir string   `json:"dir"`
+	TTL Duration `json:"ttl"`
+}
+
+// Logging configures payload truncation and opt-in prompt logging
+type Logging struct {
+	PayloadLimit  int    `json:"payload_limit"`
+	PromptLogFile string `json:"prompt_log_file"`
+}
+
+// Duration is a time.Duration written as a string like "72h" in JSON
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"72h\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// Default returns the settings used for anything config.json leaves out
+func Default() Config {
+	return Config{
+		NumSitesPerQuery: 2,
+		PageTokenBudget:  3000,
+		CallTokenBudget:  12000,
+		Server: Server{
+			Port: "4000",
+			CORS: CORS{
+				Origins: []string{
+					"http://localhost:5173",
+					"https://www.thewriterco.com",
+					"https://thewriterco.com",
+					"https://thewriterco.pages.dev",
+					"https://*.thewriterco.pages.dev",
+				},
+				Methods: []string{"GET", "OPTIONS"},
+				Headers: []string{"Content-Type", "If-None-Match", "If-Modified-Since"},
+				MaxAge:  Duration{10 * time.Minute},
+			},
+			CacheMaxAge: Duration{time.Minute},
+		},
+		Reports: Reports{
+			Dir:           "./reports",
+			Expiration:    Duration{72 * time.Hour}, // 3 days
+			ReportingHour: 8,
+			DeleteAfter:   Duration{30 * 24 * time.Hour},
+		},
+		Cache: Cache{
+			Dir: "./cache/responses",
+			TTL: Duration{24 * time.Hour},
+		},
+		Logging: Logging{PayloadLimit: 512},
+		KeysEnv: "GEMINI_API_KEYS_POOL",
+	}
+}
+
+// Idiomatic load function for config: defaults, then the file, then
+// environment overrides, then validation of the result
 func Load(path string) (*Config, error) {
 	file, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	var cfg Config
-	err = json.Unmarshal(file, &cfg)
-	return &cfg, err
+
+	cfg := Default()
+	if err := json.Unmarshal(file, &cfg); err != nil {
+		return &cfg, err
+	}
+
+	problems := cfg.applyEnv(os.LookupEnv)
+	problems = append(problems, cfg.migrate()...)
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return &cfg, &ValidationError{Path: path, Problems: problems}
+	}
+	cfg.Server.CORS.compile()
+	return &cfg, nil
+}
+
+// Moves deprecated settings into their replacements, reporting problems under
+// the deprecated names so they can be found in config.json
+func (c *Config) migrate() []string {
+	var problems []string
+	for i, origin := range c.Server.CORSOrigins {
+		path := fmt.Sprintf("server.cors_origins[%d]", i)
+		origin = strings.TrimSpace(origin)
+
+		// Browsers always send a scheme, so these never matched before
+		if !strings.Contains(origin, "://") && !strings.HasPrefix(origin, regexOriginPrefix) {
+			log.Printf("⚠️ %s: %q has no scheme, allowing https://%s; list it under server.cors.origins instead", path, origin, origin)
+			origin = "https://" + origin
+		}
+		if _, err := parseOrigin(origin); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		c.Server.CORS.Origins = append(c.Server.CORS.Origins, origin)
+	}
+	c.Server.CORSOrigins = nil
+	return problems
+}
+
+// ValidationError lists every invalid setting found in a config
+type ValidationError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid config %s:\n  %s", e.Path, strings.Join(e.Problems, "\n  "))
+}
+
+// Environment variables overriding config.json. The unprefixed legacy names
+// are still honored, with the NEWS_ names taking precedence.
+func (c *Config) applyEnv(lookup func(string) (string, bool)) []string {
+	var problems []string
+	get := func(names ...string) (string, string, bool) {
+		for i := len(names) - 1; i >= 0; i-- {
+			if v, ok := lookup(names[i]); ok && v != "" {
+				return names[i], v, true
+			}
+		}
+		return "", "", false
+	}
+	integer := func(dst *int, names ...string) {
+		if name, v, ok := get(names...); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q is not an integer", name, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	duration := func(dst *Duration, names ...string) {
+		if name, v, ok := get(names...); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+				return
+			}
+			dst.Duration = d
+		}
+	}
+	str := func(dst *string, names ...string) {
+		if _, v, ok := get(names...); ok {
+			*dst = v
+		}
+	}
+
+	str(&c.Server.Port, "PORT", "NEWS_PORT")
+	str(&c.Server.StayAliveURL, "STAY_ALIVE_API_URL", "NEWS_STAY_ALIVE_URL")
+	str(&c.Server.AdminToken, "ADMIN_TOKEN", "NEWS_ADMIN_TOKEN")
+	if _, v, ok := get("NEWS_CORS_ORIGINS"); ok {
+		c.Server.CORS.Origins = splitList(v)
+	}
+
+	str(&c.Reports.Dir, "NEWS_REPORTS_DIR")
+	duration(&c.Reports.Expiration, "NEWS_REPORT_EXPIRATION")
+	integer(&c.Reports.ReportingHour, "NEWS_REPORTING_HOUR")
+	duration(&c.Reports.DeleteAfter, "NEWS_REPORT_DELETE_AFTER")
+
+	str(&c.Cache.Dir, "NEWS_CACHE_DIR")
+	duration(&c.Cache.TTL, "NEWS_CACHE_TTL")
+
+	integer(&c.Logging.PayloadLimit, "LOG_PAYLOAD_LIMIT", "NEWS_LOG_PAYLOAD_LIMIT")
+	str(&c.Logging.PromptLogFile, "PROMPT_LOG_FILE", "NEWS_PROMPT_LOG_FILE")
+
+	integer(&c.NumSitesPerQuery, "NEWS_NUM_SITES_PER_QUERY")
+	str(&c.KeysEnv, "NEWS_KEYS_ENV")
+	if _, v, ok := get("NEWS_MODELS"); ok {
+		c.Models = splitList(v)
+	}
+
+	return problems
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Returns one message per invalid field
+func (c *Config) validate() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	problems = append(problems, c.validateChannels()...)
+	for i, keyword := range c.Keywords {
+		if strings.TrimSpace(keyword.Query) == "" {
+			add("keywords[%d].query: must not be empty", i)
+		}
+		if _, ok := c.Instructions.Profiles[keyword.Profile]; keyword.Profile != "" && !ok {
+			add("keywords[%d].profile: undefined instruction profile %q", i, keyword.Profile)
+		}
+	}
+	if c.NumSitesPerQuery < 1 {
+		add("num_sites_per_query: must be at least 1, got %d", c.NumSitesPerQuery)
+	}
+	if c.PageTokenBudget < 0 {
+		add("page_token_budget: must not be negative, got %d", c.PageTokenBudget)
+	}
+	if c.CallTokenBudget < 0 {
+		add("call_token_budget: must not be negative, got %d", c.CallTokenBudget)
+	}
+	for i, model := range c.Models {
+		if strings.TrimSpace(model) == "" {
+			add("models[%d]: must not be empty", i)
+		}
+	}
+	if err := c.Parameters.Validate(c.Models); err != nil {
+		problems = append(problems, strings.Split(err.Error(), "\n")...)
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		add("server.port: %q is not a valid port", c.Server.Port)
+	}
+
+	problems = append(problems, c.Server.CORS.validate()...)
+	if c.Server.CacheMaxAge.Duration < 0 {
+		add("server.cache_max_age: must not be negative, got %s", c.Server.CacheMaxAge)
+	}
+
+	if strings.TrimSpace(c.Reports.Dir) == "" {
+		add("reports.dir: must not be empty")
+	}
+	if c.Reports.Expiration.Duration <= 0 {
+		add("reports.expiration: must be positive, got %s", c.Reports.Expiration)
+	}
+	problems = append(problems, validateRetention("reports", c.Reports.Expiration, c.Reports.TagExpiration, c.Reports.DeleteAfter)...)
+	if c.Reports.ReportingHour < 0 || c.Reports.ReportingHour > 23 {
+		add("reports.reporting_hour: must be between 0 and 23, got %d", c.Reports.ReportingHour)
+	}
+
+	if strings.TrimSpace(c.Cache.Dir) == "" {
+		add("cache.dir: must not be empty")
+	}
+	if c.Cache.TTL.Duration < 0 {
+		add("cache.ttl: must not be negative, got %s", c.Cache.TTL)
+	}
+
+	if c.Logging.PayloadLimit < 0 {
+		add("logging.payload_limit: must not be negative, got %d", c.Logging.PayloadLimit)
+	}
+	if strings.TrimSpace(c.KeysEnv) == "" {
+		add("keys_env: must name an environment variable")
+	}
+
+	return problems
 }
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(c *Config) bool
+		wantErr string
+	}{
+		{"legacy name", map[string]string{"PORT": "5000"}, func(c *Config) bool { return c.Server.Port == "5000" }, ""},
+		{"prefixed name wins", map[string]string{"PORT": "5000", "NEWS_PORT": "6000"}, func(c *Config) bool { return c.Server.Port == "6000" }, ""},
+		{"empty is unset", map[string]string{"NEWS_PORT": ""}, func(c *Config) bool { return c.Server.Port == "4000" }, ""},
+		{"list", map[string]string{"NEWS_MODELS": " a, ,b "}, func(c *Config) bool { return slices.Equal(c.Models, []string{"a", "b"}) }, ""},
+		{"cors origins", map[string]string{"NEWS_CORS_ORIGINS": "https://a.com,https://b.com"}, func(c *Config) bool {
+			return slices.Equal(c.Server.CORS.Origins, []string{"https://a.com", "https://b.com"})
+		}, ""},
+		{"duration", map[string]string{"NEWS_REPORT_DELETE_AFTER": "48h"}, func(c *Config) bool { return c.Reports.DeleteAfter.Duration == 48*time.Hour }, ""},
+		{"bad duration", map[string]string{"NEWS_CACHE_TTL": "soon"}, nil, "NEWS_CACHE_TTL"},
+		{"bad integer", map[string]string{"NEWS_REPORTING_HOUR": "six"}, nil, `NEWS_REPORTING_HOUR: "six" is not an integer`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			problems := strings.Join(cfg.applyEnv(func(name string) (string, bool) {
+				v, ok := tt.env[name]
+				return v, ok
+			}), "\n")
+
+			if tt.wantErr != "" {
+				if !strings.Contains(problems, tt.wantErr) {
+					t.Errorf("applyEnv problems %q, want %q", problems, tt.wantErr)
+				}
+				return
+			}
+			if problems != "" {
+				t.Fatalf("applyEnv problems %q", problems)
+			}
+			if !tt.check(&cfg) {
+				t.Errorf("applyEnv(%v) did not apply", tt.env)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		json        string
+		wantErr     []string
+		wantOrigins []string
+		wantAllowed []string
+	}{
+		{
+			name:        "regex origin",
+			json:        `{"keywords": [{"query": "news"}], "server": {"cors": {"origins": ["regex:https://[a-z]+\\.example\\.com"]}}}`,
+			wantOrigins: []string{`regex:https://[a-z]+\.example\.com`},
+			wantAllowed: []string{"https://www.example.com"}, // Load compiles regex origins
+		},
+		{
+			name:        "legacy origins merged",
+			json:        `{"keywords": [{"query": "news"}], "server": {"cors": {"origins": ["https://a.com"]}, "cors_origins": ["https://b.com", "c.pages.dev"]}}`,
+			wantOrigins: []string{"https://a.com", "https://b.com", "https://c.pages.dev"},
+			wantAllowed: []string{"https://b.com", "https://c.pages.dev"},
+		},
+		{
+			name:    "legacy origin problems use the legacy path",
+			json:    `{"server": {"cors_origins": ["https://b.com/app"]}}`,
+			wantErr: []string{`server.cors_origins[0]: "https://b.com/app" must not have a path`},
+		},
+		{
+			name:    "every problem reported",
+			json:    `{"num_sites_per_query": 0, "server": {"port": "http"}, "reports": {"reporting_hour": 24}}`,
+			wantErr: []string{"num_sites_per_query", "server.port", "reports.reporting_hour"},
+		},
+		{
+			name:    "parameters checked",
+			json:    `{"models": ["gemini-2.0-flash"], "parameters": {"stages": {"draft": {}}}}`,
+			wantErr: []string{"parameters.stages.draft"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := Load(path)
+			if len(tt.wantErr) > 0 {
+				var invalid *ValidationError
+				if !errors.As(err, &invalid) {
+					t.Fatalf("Load = %v, want a ValidationError", err)
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("Load = %q, want it to mention %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(cfg.Server.CORS.Origins, tt.wantOrigins) || cfg.Server.CORSOrigins != nil {
+				t.Errorf("origins %q (legacy %q), want %q", cfg.Server.CORS.Origins, cfg.Server.CORSOrigins, tt.wantOrigins)
+			}
+			for _, origin := range tt.wantAllowed {
+				if !cfg.Server.CORS.Allows(origin) {
+					t.Errorf("Allows(%q) = false, want true", origin)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Origins starting with this prefix are regular expressions
+const regexOriginPrefix = "regex:"
+
+// CORS configures cross-origin access to every route. Origins are exact
+// ("https://example.com"), wildcard subdomains ("https://*.example.com",
+// which does not match the apex) or regular expressions matched against the
+// whole origin ("regex:https://[a-z-]+\.example\.com").
+type CORS struct {
+	Origins []string `json:"origins"`
+	Methods []string `json:"methods"`
+	Headers []string `json:"headers"`
+	MaxAge  Duration `json:"max_age"` // how long browsers may cache a preflight
+
+	patterns []*regexp.Regexp // compiled regex origins, set by Load
+}
+
+// Allows reports whether origin may make cross-origin requests
+func (c *CORS) Allows(origin string) bool {
+	if origin == "" {
+		return false
+	}
+
+	for _, allowed := range c.Origins {
+		if strings.HasPrefix(allowed, regexOriginPrefix) {
+			continue
+		}
+		if allowed == origin {
+			return true
+		}
+
+		// At least one label must stand in for the wildcard
+		scheme, host, ok := strings.Cut(allowed, "://*.")
+		if ok && strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, "."+host) &&
+			len(origin) > len(scheme+"://."+host) {
+			return true
+		}
+	}
+
+	for _, pattern := range c.patterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns one message per invalid origin or setting
+func (c *CORS) validate() []string {
+	var problems []string
+	for i, origin := range c.Origins {
+		if _, err := parseOrigin(origin); err != nil {
+			problems = append(problems, fmt.Sprintf("server.cors.origins[%d]: %v", i, err))
+		}
+	}
+	if c.MaxAge.Duration < 0 {
+		problems = append(problems, fmt.Sprintf("server.cors.max_age: must not be negative, got %s", c.MaxAge))
+	}
+	return problems
+}
+
+// Compiles the regex origins of a validated policy
+func (c *CORS) compile() {
+	c.patterns = nil
+	for _, origin := range c.Origins {
+		if pattern, err := parseOrigin(origin); err == nil && pattern != nil {
+			c.patterns = append(c.patterns, pattern)
+		}
+	}
+}
+
+// Checks one origin, returning the compiled pattern of a regex origin.
+// Patterns are anchored so https://example\.com cannot also match
+// https://example.com.evil.net.
+func parseOrigin(origin string) (*regexp.Regexp, error) {
+	if expr, ok := strings.CutPrefix(origin, regexOriginPrefix); ok {
+		return regexp.Compile("^(?:" + expr + ")$")
+	}
+
+	u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%q must be a scheme and host like https://example.com", origin)
+	}
+	if u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("%q must not have a path", origin)
+	}
+	return nil, nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCORSAllows(t *testing.T) {
+	cors := CORS{Origins: []string{
+		"https://example.com",
+		"https://*.example.org",
+		`regex:https://[a-z]+\.pages\.dev`,
+	}}
+	if problems := cors.validate(); len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	cors.compile()
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://example.com", true},
+		{"http://example.com", false},
+		{"https://example.com.evil.net", false},
+		{"https://www.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://.example.org", false},
+		{"https://evilexample.org", false},
+		{"https://site.pages.dev", true},
+		{"https://site.pages.dev.evil.net", false},
+		{"https://evil.net/https://site.pages.dev", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if got := cors.Allows(tt.origin); got != tt.want {
+				t.Errorf("Allows(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		wantErr string
+	}{
+		{"exact", []string{"https://example.com"}, ""},
+		{"port", []string{"http://localhost:5173"}, ""},
+		{"wildcard", []string{"https://*.example.com"}, ""},
+		{"regex", []string{"regex:https://(www\\.)?example\\.com"}, ""},
+		{"no scheme", []string{"example.com"}, "server.cors.origins[0]: \"example.com\" must be a scheme and host"},
+		{"path", []string{"https://example.com", "https://example.com/app"}, "server.cors.origins[1]: \"https://example.com/app\" must not have a path"},
+		{"bad regex", []string{"regex:https://(example.com"}, "server.cors.origins[0]: error parsing regexp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cors := CORS{Origins: tt.origins}
+			problems := strings.Join(cors.validate(), "\n")
+			if tt.wantErr == "" && problems != "" || !strings.Contains(problems, tt.wantErr) {
+				t.Errorf("validate = %q, want %q", problems, tt.wantErr)
+			}
+			if cors.patterns != nil {
+				t.Errorf("validate compiled patterns")
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package config
+
+import "encoding/json"
+
+// Keyword is a search query and the instruction profile its reports use
+type Keyword struct {
+	Query   string `json:"query"`
+	Profile string `json:"profile"` // empty uses the default profile
+}
+
+// UnmarshalJSON accepts either a bare query string or a keyword object
+func (k *Keyword) UnmarshalJSON(data []byte) error {
+	var query string
+	if err := json.Unmarshal(data, &query); err == nil {
+		*k = Keyword{Query: query}
+		return nil
+	}
+
+	type plain Keyword
+	return json.Unmarshal(data, (*plain)(k))
+}
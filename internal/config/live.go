@@ -0,0 +1,224 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/renniemaharaj/news/pkg/transformer"
+)
+
+// Snapshot is a validated config together with the instructions it references
+type Snapshot struct {
+	Config       *Config
+	Instructions *transformer.Instructions
+}
+
+// Live holds the current snapshot and swaps in new versions as files change
+type Live struct {
+	path    string
+	current atomic.Pointer[Snapshot]
+
+	mu          sync.Mutex
+	stamps      map[string]stamp // last seen state of every watched file
+	subscribers []chan struct{}
+}
+
+// Settings read once at startup; changing them needs a restart
+var restartRequired = []string{"server.port", "server.stay_alive_url", "keys_env", "logging."}
+
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Open loads the config at path and its instructions
+func Open(path string) (*Live, error) {
+	l := &Live{path: path}
+	snap, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+	l.current.Store(snap)
+	l.stamps = l.scan(snap)
+	return l, nil
+}
+
+// Get returns the current snapshot; callers should hold onto it for a whole run
+func (l *Live) Get() *Snapshot {
+	return l.current.Load()
+}
+
+// Subscribe returns a channel signalled after every successful reload
+func (l *Live) Subscribe() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	l.subscribers = append(l.subscribers, ch)
+	return ch
+}
+
+func (l *Live) notify() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, ch := range l.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default: // a notification is already pending
+		}
+	}
+}
+
+// Watch polls the config and instruction files every interval and reloads
+// on change, keeping the old snapshot if the new one fails validation
+func (l *Live) Watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.mu.Lock()
+		stamps := l.scan(l.Get())
+		changed := !reflect.DeepEqual(stamps, l.stamps)
+		l.stamps = stamps
+		l.mu.Unlock()
+
+		if changed {
+			l.Reload()
+		}
+	}
+}
+
+// Reload re-reads and validates the config, swapping it in on success
+func (l *Live) Reload() error {
+	next, err := l.load()
+	if err != nil {
+		log.Printf("⚠️ Config reload failed, keeping current config: %v", err)
+		return err
+	}
+
+	prev := l.current.Swap(next)
+	defer l.notify()
+
+	changes := diff(prev, next)
+	if len(changes) == 0 {
+		log.Println("🔄 Config reloaded, no changes")
+		return nil
+	}
+	for _, change := range annotate(changes) {
+		log.Printf("🔄 Config changed: %s", change)
+	}
+	return nil
+}
+
+// Marks the changes that only take effect after a restart
+func annotate(changes []string) []string {
+	annotated := make([]string, len(changes))
+	for i, change := range changes {
+		for _, prefix := range restartRequired {
+			if strings.HasPrefix(change, prefix) {
+				change += " (takes effect after restart)"
+				break
+			}
+		}
+		annotated[i] = change
+	}
+	return annotated
+}
+
+func (l *Live) load() (*Snapshot, error) {
+	cfg, err := Load(l.path)
+	if err != nil {
+		return nil, err
+	}
+
+	instructions, err := transformer.LoadInstructions(cfg.Instructions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid instructions:\n%w", err)
+	}
+	return &Snapshot{Config: cfg, Instructions: instructions}, nil
+}
+
+// Records the modification state of the config and instruction files
+func (l *Live) scan(snap *Snapshot) map[string]stamp {
+	files := append([]string{l.path}, snap.Instructions.Files()...)
+
+	stamps := map[string]stamp{}
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			stamps[file] = stamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
+
+// Describes what changed between two snapshots, one line per setting
+func diff(prev, next *Snapshot) []string {
+	before, after := flatten(prev.Config), flatten(next.Config)
+
+	keys := map[string]struct{}{}
+	for k := range before {
+		keys[k] = struct{}{}
+	}
+	for k := range after {
+		keys[k] = struct{}{}
+	}
+
+	var changes []string
+	for k := range keys {
+		if before[k] != after[k] {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", k, orNone(before[k]), orNone(after[k])))
+		}
+	}
+
+	// Template files are compared by content hash
+	for name := range next.Config.Instructions.Profiles {
+		if prev.Instructions.Hash(name) != next.Instructions.Hash(name) {
+			changes = append(changes, fmt.Sprintf("instructions.%s: template changed", name))
+		}
+	}
+
+	sort.Strings(changes)
+	return changes
+}
+
+// Flattens a config into dotted paths and their JSON-encoded values
+func flatten(cfg *Config) map[string]string {
+	data, _ := json.Marshal(cfg)
+	var tree any
+	json.Unmarshal(data, &tree)
+
+	flat := map[string]string{}
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		if m, ok := v.(map[string]any); ok && len(m) > 0 {
+			for k, child := range m {
+				path := k
+				if prefix != "" {
+					path = prefix + "." + k
+				}
+				walk(path, child)
+			}
+			return
+		}
+		encoded, _ := json.Marshal(v)
+		flat[prefix] = string(encoded)
+	}
+	walk("", tree)
+	return flat
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Writes a config whose default profile points at a template in dir
+func writeConfig(t *testing.T, dir, settings string) string {
+	t.Helper()
+	instructions := filepath.Join(dir, "instructions.txt")
+	if err := os.WriteFile(instructions, []byte("news on {{.Keyword}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "config.json")
+	data := fmt.Sprintf(`{"keywords": [{"query": "news"}], "instructions": {"profiles": {"default": {"file": %q}}}%s}`, instructions, settings)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReloadKeepsSnapshotOnFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string // config written before the reload, raw
+	}{
+		{"malformed json", `{"keywords": [`},
+		{"invalid setting", `{"keywords": [{"query": "news"}], "server": {"port": "http"}}`},
+		{"missing instructions", `{"keywords": [{"query": "news"}], "instructions": {"profiles": {"default": {"file": "/nonexistent/instructions.txt"}}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			live, err := Open(writeConfig(t, dir, ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := live.Get()
+			reloaded := live.Subscribe()
+
+			if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(tt.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := live.Reload(); err == nil {
+				t.Fatal("Reload succeeded, want an error")
+			}
+
+			if live.Get() != before {
+				t.Errorf("snapshot replaced by a failed reload")
+			}
+			select {
+			case <-reloaded:
+				t.Errorf("subscribers notified of a failed reload")
+			default:
+			}
+		})
+	}
+}
+
+func TestReloadChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     string // substring of the reported changes, empty for none
+		restart  bool   // whether the change only takes effect after a restart
+	}{
+		{"no changes", "", "", false},
+		{"live setting", `, "num_sites_per_query": 9`, "num_sites_per_query: 2 -> 9", false},
+		{"server port", `, "server": {"port": "5000"}`, `server.port: "4000" -> "5000"`, true},
+		{"keys env", `, "keys_env": "OTHER_KEYS"`, `keys_env: "GEMINI_API_KEYS_POOL" -> "OTHER_KEYS"`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			live, err := Open(writeConfig(t, dir, ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := live.Get()
+			reloaded := live.Subscribe()
+
+			writeConfig(t, dir, tt.settings)
+			if err := live.Reload(); err != nil {
+				t.Fatal(err)
+			}
+			<-reloaded
+
+			changes := strings.Join(annotate(diff(before, live.Get())), "\n")
+			if tt.want == "" {
+				if changes != "" {
+					t.Errorf("changes %q, want none", changes)
+				}
+				return
+			}
+			if !strings.Contains(changes, tt.want) {
+				t.Errorf("changes %q, want %q", changes, tt.want)
+			}
+			if restart := strings.HasSuffix(changes, "(takes effect after restart)"); restart != tt.restart {
+				t.Errorf("changes %q marked as needing a restart = %v, want %v", changes, restart, tt.restart)
+			}
+		})
+	}
+}
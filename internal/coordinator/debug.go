@@ -0,0 +1,74 @@
+package coordinator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/renniemaharaj/news/internal/browser"
+	"github.com/renniemaharaj/news/internal/config"
+	"github.com/renniemaharaj/news/internal/model"
+	"github.com/renniemaharaj/news/internal/types"
+	"github.com/renniemaharaj/news/internal/validation"
+
+	"github.com/renniemaharaj/news/pkg/pool"
+	"github.com/renniemaharaj/news/pkg/transformer"
+)
+
+// Debug runs the pipeline once for a single URL or keyword and writes every
+// step to w: the scraped text, each exact model input, raw response and
+// validation error, and the final reports. Nothing is saved and the response
+// cache is bypassed so the model is always called.
+func Debug(cfg *config.Config, channel config.Channel, p *pool.Instance, instructions *transformer.Instructions, target string, w io.Writer) ([]types.Report, error) {
+	section := func(title, body string) {
+		fmt.Fprintf(w, "\n===== %s =====\n%s\n", title, body)
+	}
+
+	opts, err := options(cfg, instructions, channel, config.Keyword{Query: target})
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []types.Page
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		page, err := browser.Scrape(target)
+		if err != nil {
+			return nil, err
+		}
+		pages = []types.Page{page}
+	} else if pages, err = search(target, channel.NumSitesPerQuery); err != nil {
+		return nil, err
+	}
+
+	for _, page := range pages {
+		section("scraped "+page.URL, page.String())
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("nothing scraped for %q", target)
+	}
+	section("system instruction", opts.Instruction)
+
+	opts.Cache = nil
+	opts.Trace = section
+
+	wrapper, err := model.Prompt(p, pages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reports that survive repair may still fail the full check
+	if err := validation.New(pages).Validate(marshal(wrapper)); err != nil {
+		section("final validation errors", err.Error())
+	}
+	section("final reports", marshal(wrapper))
+	for _, report := range wrapper.Reports {
+		section("provenance of "+report.Title, marshal(report.Provenance))
+	}
+	return wrapper.Reports, nil
+}
+
+func marshal(v any) string {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	return string(data)
+}
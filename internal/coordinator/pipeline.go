@@ -2,34 +2,35 @@ package coordinator
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/renniemaharaj/news/internal/browser"
 	"github.com/renniemaharaj/news/internal/config"
 	"github.com/renniemaharaj/news/internal/model"
 	"github.com/renniemaharaj/news/internal/types"
+
+	"github.com/renniemaharaj/news/pkg/cache"
+	"github.com/renniemaharaj/news/pkg/pool"
+	"github.com/renniemaharaj/news/pkg/transformer"
 )
 
-// Coordinator runner
-func Run(cfg *config.Config, output chan types.Report) error {
+// Coordinator runner for one channel, p is the shared API key pool and
+// instructions the instruction profiles loaded at startup
+func Run(cfg *config.Config, channel config.Channel, p *pool.Instance, instructions *transformer.Instructions, output chan types.Report) error {
 	defer close(output) // Only coordinator closes it after sending
 
-	for _, keyword := range cfg.Keywords {
-		fmt.Println("🔍 Google searching: ", keyword)
-		urls, err := browser.Search(keyword, cfg.NumSitesPerQuery)
+	for _, keyword := range channel.Keywords {
+		opts, err := options(cfg, instructions, channel, keyword)
 		if err != nil {
 			return err
 		}
 
-		var textContents []string
-		for _, url := range urls {
-
-			content, err := browser.Scrape(url)
-			if err == nil {
-				textContents = append(textContents, content)
-			}
+		pages, err := search(keyword.Query, channel.NumSitesPerQuery)
+		if err != nil {
+			return err
 		}
 
-		reportWrapper, err := model.Prompt(textContents)
+		reportWrapper, err := model.Prompt(p, pages, opts)
 		if err != nil {
 			return err
 		}
@@ -42,3 +43,51 @@ func Run(cfg *config.Config, output chan types.Report) error {
 	}
 	return nil
 }
+
+// Searches for query and scrapes the pages found, skipping ones that fail
+func search(query string, numSites int) ([]types.Page, error) {
+	fmt.Println("🔍 Google searching: ", query)
+	urls, err := browser.Search(query, numSites)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []types.Page
+	for _, url := range urls {
+
+		page, err := browser.Scrape(url)
+		if err == nil {
+			pages = append(pages, page)
+		}
+	}
+	return pages, nil
+}
+
+// Model options for one keyword's run, rendering its system instruction
+// from the keyword's profile or else the channel's
+func options(cfg *config.Config, instructions *transformer.Instructions, channel config.Channel, keyword config.Keyword) (model.Options, error) {
+	profile := keyword.Profile
+	if profile == "" {
+		profile = channel.Profile
+	}
+	if profile == "" {
+		profile = instructions.Default
+	}
+
+	instruction, err := instructions.Render(profile, keyword.Query, time.Now())
+	if err != nil {
+		return model.Options{}, err
+	}
+
+	return model.Options{
+		Repair:          cfg.Repair,
+		PageTokenBudget: cfg.PageTokenBudget,
+		CallTokenBudget: cfg.CallTokenBudget,
+		Models:          cfg.Models,
+		Tuning:          &cfg.Parameters,
+		Instruction:     instruction,
+		Profile:         profile,
+		InstructionHash: instructions.Hash(profile),
+		Cache:           &cache.Store{Dir: cfg.Cache.Dir, TTL: cfg.Cache.TTL.Duration},
+	}, nil
+}
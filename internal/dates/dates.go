@@ -0,0 +1,92 @@
+package dates
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Absolute layouts accepted by Parse, most specific first. Layouts without a
+// zone are read as UTC.
+var layouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.ANSIC,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"January 2, 2006 15:04",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// Relative phrases such as "2 hours ago" or "an hour ago"
+var relative = regexp.MustCompile(`^(\d+|an?|one)\s+(second|sec|minute|min|hour|hr|day|week|month|year)s?\s+ago$`)
+
+var units = map[string]time.Duration{
+	"second": time.Second,
+	"sec":    time.Second,
+	"minute": time.Minute,
+	"min":    time.Minute,
+	"hour":   time.Hour,
+	"hr":     time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+}
+
+// Parse reads a date as written by a model or a news site: ISO 8601 with or
+// without time and zone, RFC 1123 and similar, or a phrase relative to now
+// like "2 hours ago" or "yesterday"
+func Parse(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	phrase := strings.ToLower(s)
+	switch phrase {
+	case "now", "just now", "today":
+		return now, nil
+	case "yesterday":
+		return now.Add(-24 * time.Hour), nil
+	}
+
+	if m := relative.FindStringSubmatch(phrase); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			n = 1 // "a", "an" or "one"
+		}
+		return now.Add(-time.Duration(n) * units[m[2]]), nil
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date %q", s)
+}
+
+// Normalize parses s and formats it as an RFC 3339 UTC timestamp
+func Normalize(s string, now time.Time) (string, error) {
+	t, err := Parse(s, now)
+	if err != nil {
+		return "", err
+	}
+	return t.UTC().Format(time.RFC3339), nil
+}
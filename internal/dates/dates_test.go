@@ -0,0 +1,70 @@
+package dates
+
+import (
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2026-10-19T08:30:00Z", time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC), false},
+		{"2026-10-19T08:30:00-04:00", time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC), false},
+		{"2026-10-19T08:30:00.5Z", time.Date(2026, 10, 19, 8, 30, 0, 5e8, time.UTC), false},
+		{"2026-10-19 08:30:00", time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC), false},
+		{"2026-10-19", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), false},
+		{"  2026-10-19  ", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), false},
+		{"Mon, 19 Oct 2026 08:30:00 GMT", time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC), false},
+		{"October 19, 2026", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), false},
+		{"19 Oct 2026", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), false},
+		{"just now", now, false},
+		{"Yesterday", now.Add(-24 * time.Hour), false},
+		{"2 hours ago", now.Add(-2 * time.Hour), false},
+		{"an hour ago", now.Add(-time.Hour), false},
+		{"3 days ago", now.Add(-72 * time.Hour), false},
+		{"", time.Time{}, true},
+		{"next tuesday", time.Time{}, true},
+		{"2026-13-01", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Parse(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"2026-10-19", "2026-10-19T00:00:00Z", false},
+		{"2026-10-19T08:30:00-04:00", "2026-10-19T12:30:00Z", false},
+		{"2026-10-19T08:30:00.9Z", "2026-10-19T08:30:00Z", false},
+		{"2 hours ago", "2026-10-19T10:00:00Z", false},
+		{"soon", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Normalize(tt.in, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"log"
+	"unicode/utf8"
+
+	"github.com/renniemaharaj/news/internal/types"
+)
+
+const (
+	// Rough characters-per-token ratio for English prose
+	charsPerToken = 4
+
+	defaultPageTokenBudget = 3000
+	defaultCallTokenBudget = 12000
+
+	truncationMarker = "\n[truncated]"
+)
+
+// Estimates the number of tokens s will consume
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + charsPerToken - 1) / charsPerToken
+}
+
+// Trims a page's text so its rendered form fits within budget tokens
+func truncatePage(page types.Page, budget int) types.Page {
+	if budget <= 0 || estimateTokens(page.String()) <= budget {
+		return page
+	}
+
+	// Images and the source URL are kept whole, only the text is cut
+	overhead := estimateTokens(page.String()) - estimateTokens(page.Text)
+	keep := (budget-overhead)*charsPerToken - utf8.RuneCountInString(truncationMarker)
+	if keep < 0 {
+		keep = 0
+	}
+
+	runes := []rune(page.Text)
+	if keep < len(runes) {
+		log.Printf("✂️ Truncating %s from %d to %d characters", page.URL, len(runes), keep)
+		page.Text = string(runes[:keep]) + truncationMarker
+	}
+	return page
+}
+
+// Splits pages into batches whose combined estimate fits within budget tokens
+func chunkPages(pages []types.Page, budget int) [][]types.Page {
+	var batches [][]types.Page
+	var batch []types.Page
+	used := 0
+
+	for _, page := range pages {
+		tokens := estimateTokens(page.String())
+		if len(batch) > 0 && budget > 0 && used+tokens > budget {
+			batches = append(batches, batch)
+			batch, used = nil, 0
+		}
+		batch = append(batch, page)
+		used += tokens
+	}
+
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
@@ -0,0 +1,177 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/renniemaharaj/news/internal/types"
+)
+
+func TestTruncatePage(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     types.Page
+		budget   int
+		wantText string // expected text, empty to only check the invariants
+		fits     bool   // whether the rendered page must fit the budget
+	}{
+		{
+			name:     "under budget",
+			page:     types.Page{URL: "u", Text: "short"},
+			budget:   100,
+			wantText: "short",
+			fits:     true,
+		},
+		{
+			name:     "no budget",
+			page:     types.Page{URL: "u", Text: strings.Repeat("a", 1000)},
+			budget:   0,
+			wantText: strings.Repeat("a", 1000),
+		},
+		{
+			name:   "ascii cut",
+			page:   types.Page{URL: "u", Text: strings.Repeat("a", 1000)},
+			budget: 50,
+			fits:   true,
+		},
+		{
+			name:   "multi-byte cut",
+			page:   types.Page{URL: "u", Text: strings.Repeat("é世🙂", 300)},
+			budget: 50,
+			fits:   true,
+		},
+		{
+			name:     "overhead larger than budget",
+			page:     types.Page{URL: "https://example.com/" + strings.Repeat("p", 400), Images: []string{"a.jpg", "b.jpg"}, Text: "hello world"},
+			budget:   10,
+			wantText: truncationMarker,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncatePage(tt.page, tt.budget)
+
+			if got.URL != tt.page.URL || len(got.Images) != len(tt.page.Images) {
+				t.Errorf("URL or images changed")
+			}
+			if !utf8.ValidString(got.Text) {
+				t.Errorf("text cut mid-rune: %q", got.Text)
+			}
+			if tt.wantText != "" && got.Text != tt.wantText {
+				t.Errorf("text = %q, want %q", got.Text, tt.wantText)
+			}
+			if kept := strings.TrimSuffix(got.Text, truncationMarker); !strings.HasPrefix(tt.page.Text, kept) {
+				t.Errorf("kept text %q is not a prefix of the original", kept)
+			}
+			if tokens := estimateTokens(got.String()); tt.fits && tokens > tt.budget {
+				t.Errorf("rendered page is %d tokens, budget %d", tokens, tt.budget)
+			}
+		})
+	}
+}
+
+func TestChunkPages(t *testing.T) {
+	page := func(url string, tokens int) types.Page {
+		p := types.Page{URL: url}
+		p.Text = strings.Repeat("a", tokens*charsPerToken-len(p.String()))
+		return p
+	}
+
+	tests := []struct {
+		name   string
+		pages  []types.Page
+		budget int
+		want   [][]string // URLs per batch
+	}{
+		{
+			name:   "empty",
+			budget: 100,
+		},
+		{
+			name:   "all fit",
+			pages:  []types.Page{page("a", 30), page("b", 30), page("c", 30)},
+			budget: 100,
+			want:   [][]string{{"a", "b", "c"}},
+		},
+		{
+			name:   "split at budget",
+			pages:  []types.Page{page("a", 50), page("b", 50), page("c", 50)},
+			budget: 100,
+			want:   [][]string{{"a", "b"}, {"c"}},
+		},
+		{
+			name:   "single page over budget",
+			pages:  []types.Page{page("a", 30), page("b", 500), page("c", 30)},
+			budget: 100,
+			want:   [][]string{{"a"}, {"b"}, {"c"}},
+		},
+		{
+			name:   "no budget",
+			pages:  []types.Page{page("a", 500), page("b", 500)},
+			budget: 0,
+			want:   [][]string{{"a", "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := chunkPages(tt.pages, tt.budget)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d batches, want %d", len(got), len(tt.want))
+			}
+			for i, batch := range got {
+				var urls []string
+				for _, p := range batch {
+					urls = append(urls, p.URL)
+				}
+				if strings.Join(urls, ",") != strings.Join(tt.want[i], ",") {
+					t.Errorf("batch %d = %v, want %v", i, urls, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMergeBatches(t *testing.T) {
+	errBatch := errors.New("batch failed")
+
+	tests := []struct {
+		name    string
+		fail    map[string]bool // batches, by first page URL, that fail
+		want    []string        // merged report titles
+		wantErr bool
+	}{
+		{name: "all succeed", want: []string{"a", "b", "c"}},
+		{name: "some fail", fail: map[string]bool{"b": true}, want: []string{"a", "c"}},
+		{name: "all fail", fail: map[string]bool{"a": true, "b": true, "c": true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batches := [][]types.Page{{{URL: "a"}}, {{URL: "b"}}, {{URL: "c"}}}
+			got, err := mergeBatches(batches, func(batch []types.Page) (types.Wrapper, error) {
+				if tt.fail[batch[0].URL] {
+					return types.Wrapper{}, errBatch
+				}
+				return types.Wrapper{Reports: []types.Report{{Title: batch[0].URL}}}, nil
+			})
+
+			if tt.wantErr {
+				if !errors.Is(err, errBatch) {
+					t.Fatalf("err = %v, want %v", err, errBatch)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var titles []string
+			for _, r := range got.Reports {
+				titles = append(titles, r.Title)
+			}
+			if strings.Join(titles, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("merged %v, want %v", titles, tt.want)
+			}
+		})
+	}
+}
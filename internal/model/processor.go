@@ -3,16 +3,62 @@ package model
 import (
 	"context"
 	"encoding/json"
+	"log"
 
 	"github.com/google/generative-ai-go/genai"
 
 	"github.com/renniemaharaj/news/internal/types"
 	"github.com/renniemaharaj/news/internal/validation"
 
+	"github.com/renniemaharaj/news/pkg/cache"
 	"github.com/renniemaharaj/news/pkg/pool"
+	"github.com/renniemaharaj/news/pkg/transformer"
 	"github.com/renniemaharaj/news/pkg/transformer/gemi"
 )
 
+// Options tunes how a batch of pages is turned into reports
+type Options struct {
+	// Repair keeps valid reports and re-prompts only the invalid ones
+	Repair bool
+	// PageTokenBudget caps the estimated tokens of a single scraped page
+	PageTokenBudget int
+	// CallTokenBudget caps the estimated input tokens of a single model call
+	CallTokenBudget int
+	// Models is the ordered fallback chain of model bases, empty uses each key's own base
+	Models []string
+	// Tuning overrides model parameters per model and per stage
+	Tuning *transformer.Tuning
+	// Instruction is the rendered system instruction for this keyword
+	Instruction string
+	// Profile and InstructionHash identify the instruction template version
+	Profile         string
+	InstructionHash string
+	// Cache stores validated responses; nil disables caching
+	Cache *cache.Store
+	// Trace observes every model exchange, for debugging; nil disables it
+	Trace func(label, payload string)
+}
+
+// Pipeline stages, used to select stage parameter overrides
+const (
+	StageReport = transformer.StageReport
+	StageRepair = transformer.StageRepair
+)
+
+func (o Options) pageBudget() int {
+	if o.PageTokenBudget > 0 {
+		return o.PageTokenBudget
+	}
+	return defaultPageTokenBudget
+}
+
+func (o Options) callBudget() int {
+	if o.CallTokenBudget > 0 {
+		return o.CallTokenBudget
+	}
+	return defaultCallTokenBudget
+}
+
 // Constructs an input for transformer communication
 func getInput(content []string) gemi.Input {
 	contentBytes, err := json.Marshal(content)
@@ -22,6 +68,7 @@ func getInput(content []string) gemi.Input {
 			Current: genai.Text(string(contentBytes)),
 			History: HISTORY,
 			Context: []map[string]string{},
+			Schema:  responseSchema,
 		}
 	}
 
@@ -33,24 +80,126 @@ const (
 	backoff = 2
 )
 
-// Prompt function interfaces with transformer package on our behalf
-func Prompt(content []string) (types.Wrapper, error) {
-	p := pool.Instance{}
-	p.InitializePool()
+// Response schema for models that support constrained JSON output
+var responseSchema = transformer.Schema(types.Wrapper{})
+
+// Looks up a previously validated response for any base in the pool
+func cached(responses *cache.Store, bases []string, instruction, input string, validate func(resp string) error) (string, bool) {
+	if responses == nil {
+		return "", false
+	}
+	for _, base := range bases {
+		resp, ok := responses.Get(cache.Key(base, instruction, input))
+		if !ok {
+			continue
+		}
+		if err := validate(resp); err != nil {
+			continue
+		}
+		log.Printf("♻️ Using cached %s response", base)
+		return resp, true
+	}
+	return "", false
+}
+
+// Prompt function interfaces with transformer package on our behalf, drawing
+// keys from the process-wide pool p
+func Prompt(p *pool.Instance, pages []types.Page, opts Options) (types.Wrapper, error) {
+	truncated := make([]types.Page, len(pages))
+	for i, page := range pages {
+		truncated[i] = truncatePage(page, opts.pageBudget())
+	}
+
+	batches := chunkPages(truncated, opts.callBudget())
+	if len(batches) > 1 {
+		log.Printf("📦 Splitting %d pages into %d model calls", len(pages), len(batches))
+	}
+
+	return mergeBatches(batches, func(batch []types.Page) (types.Wrapper, error) {
+		return promptBatch(p, batch, opts)
+	})
+}
+
+// Merges the reports of every batch, failing only if none succeed
+func mergeBatches(batches [][]types.Page, prompt func([]types.Page) (types.Wrapper, error)) (types.Wrapper, error) {
+	var merged types.Wrapper
+	var lastErr error
+	for i, batch := range batches {
+		reports, err := prompt(batch)
+		if err != nil {
+			log.Printf("⚠️ Batch %d of %d failed: %v", i+1, len(batches), err)
+			lastErr = err
+			continue
+		}
+		merged.Reports = append(merged.Reports, reports.Reports...)
+	}
+
+	if len(merged.Reports) == 0 && lastErr != nil {
+		return types.Wrapper{}, lastErr
+	}
+	return merged, nil
+}
+
+// Sends one batch of pages to the model and returns its validated reports
+func promptBatch(p *pool.Instance, pages []types.Page, opts Options) (types.Wrapper, error) {
+	content := make([]string, len(pages))
+	for i, page := range pages {
+		content[i] = page.String()
+	}
+
+	instruction := opts.Instruction
+	input := getInput(content)
+	input.Stage, input.Tuning = StageReport, opts.Tuning
+	input.Instruction = instruction
+	input.Trace = opts.Trace
+	validator := validation.New(pages)
+
+	var reports types.Wrapper
+
+	bases := opts.Models
+	if len(bases) == 0 {
+		bases = p.Bases()
+	}
+	if resp, ok := cached(opts.Cache, bases, instruction, input.String(), validator.Validate); ok {
+		var entry cachedReports
+		err := json.Unmarshal([]byte(resp), &entry)
+		return entry.restore(), err
+	}
+
+	validate := validator.Validate
+	if opts.Repair {
+		validate = validator.Partial
+	}
+	tries := &attempts{}
+	validate = tries.wrap(validate)
 
 	// call the transformer package, queued, exponential backoff and validation
-	resp, err := p.QueuedEVS(context.Background(), getInput(content), validation.Validate, queues, backoff)
+	result, err := p.QueuedEVS(context.Background(), opts.Models, input, validate, queues, backoff)
 	if err != nil {
 		return types.Wrapper{}, err
 	}
 
-	var reports types.Wrapper
-
 	// queuedEVS already handles validation into
-	err = json.Unmarshal([]byte(resp), &reports)
+	err = json.Unmarshal([]byte(result.Response), &reports)
 	if err != nil {
 		return types.Wrapper{}, err
 	}
 
+	for i := range reports.Reports {
+		reports.Reports[i].Model = result.Base
+	}
+	attachProvenance(reports.Reports, pages, provenance(opts, StageReport, result, tries))
+
+	if opts.Repair {
+		reports = repairReports(context.Background(), p, opts, validator, reports)
+	}
+
+	// Cache the final reports so a replay never needs repairing again
+	if data, err := json.Marshal(cacheEntry(reports)); err == nil && opts.Cache != nil {
+		if err := opts.Cache.Put(cache.Key(result.Base, instruction, input.String()), result.Base, string(data)); err != nil {
+			log.Printf("⚠️ Failed to cache model response: %v", err)
+		}
+	}
+
 	return reports, nil
 }
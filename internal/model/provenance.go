@@ -0,0 +1,114 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/renniemaharaj/news/internal/types"
+	"github.com/renniemaharaj/news/internal/validation"
+
+	"github.com/renniemaharaj/news/pkg/pool"
+)
+
+// Counts the replies of one model call and the validation errors they hit
+type attempts struct {
+	count  int
+	errors []string
+}
+
+// Wraps validate so every reply it checks is recorded
+func (a *attempts) wrap(validate func(resp string) error) func(resp string) error {
+	return func(resp string) error {
+		a.count++
+		err := validate(resp)
+		if err != nil {
+			a.errors = append(a.errors, err.Error())
+		}
+		return err
+	}
+}
+
+// Builds the provenance shared by every report of one model call
+func provenance(opts Options, stage string, result pool.Result, tries *attempts) types.Provenance {
+	params := result.Parameters
+	return types.Provenance{
+		GeneratedAt: time.Now().UTC(),
+		Stage:       stage,
+		Model:       result.Base,
+		Key:         result.Key,
+		Parameters: types.ModelParameters{
+			Temperature:      params.Temperature,
+			TopK:             params.TopK,
+			TopP:             params.TopP,
+			MaxOutputTokens:  params.MaxOutputTokens,
+			ResponseMIMEType: params.ResponseMIMEType,
+			Applied:          result.Applied,
+		},
+		Profile:          opts.Profile,
+		InstructionHash:  opts.InstructionHash,
+		Attempts:         tries.count,
+		ValidationErrors: tries.errors,
+	}
+}
+
+// Attaches a copy of prov to each report, with the sources it was built from
+func attachProvenance(reports []types.Report, pages []types.Page, prov types.Provenance) {
+	for i := range reports {
+		p := prov
+		p.Sources = sourcesOf(reports[i], pages)
+		reports[i].Provenance = &p
+	}
+}
+
+// The page a report cites, or every page of the call when it cites none of them
+func sourcesOf(report types.Report, pages []types.Page) []types.Source {
+	for _, page := range pages {
+		if validation.SameURL(page.URL, report.URL) {
+			return []types.Source{source(page)}
+		}
+	}
+
+	all := make([]types.Source, len(pages))
+	for i, page := range pages {
+		all[i] = source(page)
+	}
+	return all
+}
+
+func source(page types.Page) types.Source {
+	sum := sha256.Sum256([]byte(page.String()))
+	return types.Source{
+		URL:         page.URL,
+		ContentHash: hex.EncodeToString(sum[:]),
+		FetchedAt:   page.FetchedAt,
+	}
+}
+
+// cachedReports is a cache entry: the final reports with the provenance they
+// were generated with, which a report does not serialize itself
+type cachedReports struct {
+	types.Wrapper
+	Provenance []*types.Provenance `json:"provenance,omitempty"`
+}
+
+func cacheEntry(reports types.Wrapper) cachedReports {
+	entry := cachedReports{Wrapper: reports, Provenance: make([]*types.Provenance, len(reports.Reports))}
+	for i, report := range reports.Reports {
+		entry.Provenance[i] = report.Provenance
+	}
+	return entry
+}
+
+// Reattaches the original provenance, marked cached. Entries written before
+// provenance was cached leave it unset, as it is unknown.
+func (c cachedReports) restore() types.Wrapper {
+	for i := range c.Reports {
+		if i < len(c.Provenance) && c.Provenance[i] != nil {
+			prov := *c.Provenance[i]
+			prov.Cached = true
+			c.Reports[i].Provenance = &prov
+		}
+	}
+	return c.Wrapper
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/renniemaharaj/news/internal/types"
+)
+
+func TestCachedReportsRoundTrip(t *testing.T) {
+	prov := &types.Provenance{Stage: StageRepair, Model: "gemini-2.0-flash", Key: "key:abcd", Attempts: 3}
+
+	tests := []struct {
+		name  string
+		entry string // cached JSON, empty to build one from reports
+		in    []types.Report
+		want  []*types.Provenance
+	}{
+		{
+			name: "provenance restored",
+			in:   []types.Report{{Title: "a", Provenance: prov}, {Title: "b"}},
+			want: []*types.Provenance{prov, nil},
+		},
+		{
+			name:  "entry without provenance",
+			entry: `{"reports": [{"title": "a"}]}`,
+			want:  []*types.Provenance{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte(tt.entry)
+			if tt.entry == "" {
+				var err error
+				if data, err = json.Marshal(cacheEntry(types.Wrapper{Reports: tt.in})); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var entry cachedReports
+			if err := json.Unmarshal(data, &entry); err != nil {
+				t.Fatal(err)
+			}
+			got := entry.restore()
+			for i, want := range tt.want {
+				prov := got.Reports[i].Provenance
+				switch {
+				case want == nil && prov != nil:
+					t.Errorf("report %d: provenance %+v, want none", i, prov)
+				case want != nil && prov == nil:
+					t.Errorf("report %d: provenance missing", i)
+				case want != nil && (!prov.Cached || prov.Attempts != want.Attempts || prov.Key != want.Key || prov.Stage != want.Stage):
+					t.Errorf("report %d: provenance %+v, want %+v marked cached", i, prov, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSourcesOf(t *testing.T) {
+	pages := []types.Page{{URL: "https://example.com/a"}, {URL: "https://example.com/b"}}
+
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{"https://example.com/a", []string{"https://example.com/a"}},
+		{"https://example.com/b/", []string{"https://example.com/b"}},
+		{"https://example.com/b#comments", []string{"https://example.com/b"}},
+		{"https://elsewhere.com", []string{"https://example.com/a", "https://example.com/b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got := sourcesOf(types.Report{URL: tt.url}, pages)
+			if len(got) != len(tt.want) {
+				t.Fatalf("sourcesOf = %v, want %v", got, tt.want)
+			}
+			for i, source := range got {
+				if source.URL != tt.want[i] {
+					t.Errorf("source %d = %s, want %s", i, source.URL, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/renniemaharaj/news/internal/types"
+	"github.com/renniemaharaj/news/internal/validation"
+
+	"github.com/renniemaharaj/news/pkg/pool"
+)
+
+const (
+	repairQueues  = 1
+	repairBackoff = 2
+)
+
+// Keeps valid reports and re-prompts each invalid one with a targeted correction
+func repairReports(ctx context.Context, p *pool.Instance, opts Options, validator *validation.Validator, wrapper types.Wrapper) types.Wrapper {
+	var kept []types.Report
+	for i, report := range wrapper.Reports {
+		// Numbered as the only report of its correction prompt
+		violations := validator.Report(0, report)
+		if len(violations) == 0 {
+			kept = append(kept, report)
+			continue
+		}
+
+		log.Printf("🔧 Repairing report %d (%s): %v", i, report.Title, violations.Fields())
+		repaired, err := repairReport(ctx, p, opts, validator, report, violations)
+		if err != nil {
+			log.Printf("⚠️ Dropping report %d (%s): %v", i, report.Title, err)
+			continue
+		}
+		kept = append(kept, repaired)
+	}
+
+	return types.Wrapper{Reports: kept}
+}
+
+// Asks the model to correct only the offending fields of a single report
+func repairReport(ctx context.Context, p *pool.Instance, opts Options, validator *validation.Validator, report types.Report, violations validation.Violations) (types.Report, error) {
+	// Only send the page the report was generated from, when it is known
+	sources := validator.Sources
+	for _, page := range validator.Sources {
+		if validation.SameURL(page.URL, report.URL) {
+			sources = []types.Page{page}
+			break
+		}
+	}
+
+	content := make([]string, len(sources))
+	for i, page := range sources {
+		content[i] = page.String()
+	}
+
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		return types.Report{}, err
+	}
+
+	input := getInput(content)
+	input.Stage, input.Tuning = StageRepair, opts.Tuning
+	input.Instruction = opts.Instruction
+	input.Trace = opts.Trace
+	input.Context = append(input.Context, map[string]string{
+		"task":   "Return exactly one corrected report for the source above. Fix only the listed fields and keep every other field unchanged.",
+		"report": string(reportBytes),
+	})
+	input.SendError(violations)
+
+	tries := &attempts{errors: []string{violations.Error()}}
+	result, err := p.QueuedEVS(ctx, opts.Models, input, tries.wrap(validator.Validate), repairQueues, repairBackoff)
+	if err != nil {
+		return types.Report{}, err
+	}
+
+	var corrected types.Wrapper
+	if err := json.Unmarshal([]byte(result.Response), &corrected); err != nil {
+		return types.Report{}, err
+	}
+	if len(corrected.Reports) != 1 {
+		return types.Report{}, fmt.Errorf("expected 1 repaired report, got %d", len(corrected.Reports))
+	}
+
+	repaired := corrected.Reports[0]
+	repaired.Repaired = violations.Fields()
+	repaired.Model = result.Base
+
+	// The repair's provenance carries over the attempts of the original call
+	prov := provenance(opts, StageRepair, result, tries)
+	if report.Provenance != nil {
+		prov.Attempts += report.Provenance.Attempts
+		prov.ValidationErrors = slices.Concat(report.Provenance.ValidationErrors, prov.ValidationErrors)
+	}
+	prov.Sources = sourcesOf(repaired, sources)
+	repaired.Provenance = &prov
+	return repaired, nil
+}
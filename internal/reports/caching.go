@@ -0,0 +1,179 @@
+package reports
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Derives an ETag from the store version and the normalized query
+func etag(version string, query url.Values) string {
+	return contentTag([]byte(version + "?" + query.Encode()))
+}
+
+// Derives an ETag from a response body
+func contentTag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// Strong ETags must differ between encodings, so gzipped responses carry
+// their identity tag with this suffix
+const gzipSuffix = "-gz"
+
+func gzipTag(tag string) string {
+	if !strings.HasSuffix(tag, `"`) || strings.HasSuffix(tag, gzipSuffix+`"`) {
+		return tag
+	}
+	return strings.TrimSuffix(tag, `"`) + gzipSuffix + `"`
+}
+
+// Sets the validators and Cache-Control on w and answers a conditional
+// request for an unchanged response with 304. It reports whether the caller
+// should still write the body.
+func cacheable(w http.ResponseWriter, r *http.Request, tag string, modified time.Time, maxAge time.Duration) bool {
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, must-revalidate", int(maxAge.Seconds())))
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if etagMatches(match, tag) {
+			w.WriteHeader(http.StatusNotModified)
+			return false
+		}
+		return true
+	}
+
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.IsZero() && !modified.After(since) {
+		w.WriteHeader(http.StatusNotModified)
+		return false
+	}
+	return true
+}
+
+// Reports whether an If-None-Match header lists tag, comparing weakly
+func etagMatches(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// GzipMiddleware compresses responses for clients that accept gzip. Brotli
+// would need a third-party encoder, so it is not offered.
+func GzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Handlers compare against identity tags
+		if match := r.Header.Get("If-None-Match"); strings.Contains(match, gzipSuffix) {
+			r = r.Clone(r.Context())
+			r.Header.Set("If-None-Match", strings.ReplaceAll(match, gzipSuffix+`"`, `"`))
+		}
+
+		gw := &gzipWriter{ResponseWriter: w}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// Reports whether an Accept-Encoding header allows gzip with a nonzero
+// quality, by name or else through *
+func acceptsGzip(header string) bool {
+	gzipQ, anyQ := -1.0, -1.0
+	for _, coding := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		switch name = strings.TrimSpace(name); {
+		case strings.EqualFold(name, "gzip"):
+			gzipQ = quality(params)
+		case name == "*":
+			anyQ = quality(params)
+		}
+	}
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return anyQ > 0
+}
+
+// Reads the q parameter of a content coding, 1 when absent and 0 when invalid
+func quality(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		key, value, _ := strings.Cut(param, "=")
+		if !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || q < 0 || q > 1 {
+			return 0
+		}
+		return q
+	}
+	return 1
+}
+
+// Compresses the body once one is written, so bodiless responses like 304
+// go out untouched
+type gzipWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+	compress    bool
+}
+
+func (w *gzipWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if status != http.StatusNotModified && status != http.StatusNoContent && w.Header().Get("Content-Encoding") == "" {
+		w.compress = true
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+	}
+	// A 304 confirms the gzipped copy the client was sent before
+	if tag := w.Header().Get("ETag"); tag != "" && (w.compress || status == http.StatusNotModified) {
+		w.Header().Set("ETag", gzipTag(tag))
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.compress {
+		return w.ResponseWriter.Write(b)
+	}
+
+	if w.gz == nil {
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	return w.gz.Write(b)
+}
+
+func (w *gzipWriter) close() {
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
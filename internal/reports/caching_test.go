@@ -0,0 +1,147 @@
+package reports
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"x", "abc"`, true},
+		{`*`, true},
+		{`"abcd"`, false},
+		{`abc`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := etagMatches(tt.header, `"abc"`); got != tt.want {
+				t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"GZIP", true},
+		{"deflate, gzip;q=0.5", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0.0", false},
+		{"gzip;q=0.000", false},
+		{"gzip;q=junk", false},
+		{"br", false},
+		{"*", true},
+		{"*;q=0", false},
+		{"gzip;q=0, *", false},
+		{"identity, *;q=0.1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := acceptsGzip(tt.header); got != tt.want {
+				t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	modified := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"unconditional", nil, true},
+		{"etag match", map[string]string{"If-None-Match": `"abc"`}, false},
+		{"etag mismatch", map[string]string{"If-None-Match": `"old"`}, true},
+		{"not modified since", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, false},
+		{"modified since", map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, true},
+		{"etag wins over date", map[string]string{"If-None-Match": `"old"`, "If-Modified-Since": modified.Format(http.TimeFormat)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/reports", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			if got := cacheable(w, r, `"abc"`, modified, time.Minute); got != tt.want {
+				t.Fatalf("cacheable = %v, want %v", got, tt.want)
+			}
+			if !tt.want && w.Code != http.StatusNotModified {
+				t.Errorf("status %d, want 304", w.Code)
+			}
+			if w.Header().Get("ETag") != `"abc"` || w.Header().Get("Cache-Control") != "public, max-age=60, must-revalidate" {
+				t.Errorf("validators %v", w.Header())
+			}
+		})
+	}
+}
+
+func TestGzipETag(t *testing.T) {
+	handler := GzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !cacheable(w, r, `"abc"`, time.Time{}, time.Minute) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"reports": []}`)
+	}))
+
+	tests := []struct {
+		name         string
+		encoding     string
+		ifNoneMatch  string
+		wantStatus   int
+		wantETag     string
+		wantEncoding string
+	}{
+		{"identity", "", "", http.StatusOK, `"abc"`, ""},
+		{"gzip", "gzip", "", http.StatusOK, `"abc-gz"`, "gzip"},
+		{"gzip revalidated", "gzip", `"abc-gz"`, http.StatusNotModified, `"abc-gz"`, ""},
+		{"identity revalidated", "", `"abc"`, http.StatusNotModified, `"abc"`, ""},
+		{"identity sent a gzip tag", "", `"abc-gz"`, http.StatusOK, `"abc"`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/reports", nil)
+			if tt.encoding != "" {
+				r.Header.Set("Accept-Encoding", tt.encoding)
+			}
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus || w.Header().Get("ETag") != tt.wantETag || w.Header().Get("Content-Encoding") != tt.wantEncoding {
+				t.Fatalf("got %d ETag %s encoding %q, want %d %s %q", w.Code, w.Header().Get("ETag"), w.Header().Get("Content-Encoding"), tt.wantStatus, tt.wantETag, tt.wantEncoding)
+			}
+			if tt.wantEncoding == "gzip" {
+				gz, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if body, _ := io.ReadAll(gz); string(body) != `{"reports": []}` {
+					t.Errorf("body %q", body)
+				}
+			}
+		})
+	}
+}
@@ -1,22 +1,121 @@
 package reports
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/renniemaharaj/news/internal/config"
+
+	"github.com/renniemaharaj/news/pkg/pool"
 )
 
-// HealthHandler responds to healthcheck requests
-func HealthHandler(version string) http.HandlerFunc {
+// HealthHandler responds to healthcheck requests with the key pool's stats
+func HealthHandler(version string, p *pool.Instance) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "OK %s\n%s", version, p.Stats())
+	}
+}
+
+// PoolReloadHandler reloads the key pool from the environment. It is disabled
+// unless an admin token is configured, and requires it as a bearer token. The
+// token is read from the current config, so a reload rotates it.
+func PoolReloadHandler(p *pool.Instance, live *config.Live) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		token := live.Get().Config.Server.AdminToken
+		if token == "" {
+			http.Error(w, "Admin endpoints disabled", http.StatusForbidden)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := p.Reload(); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to reload keys: %v", err), http.StatusInternalServerError)
+			return
+		}
+
 		w.Header().Set("Content-Type", "text/plain")
-		fmt.Fprintf(w, "OK %s", version)
+		fmt.Fprint(w, p.Stats())
+	}
+}
+
+// Request handler, serving the default channel of the current config
+func HandleReportRequests(live *config.Live) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := live.Get().Config
+		handleReportRequests(cfg.Reports.Dir, cfg.Server.CacheMaxAge.Duration, w, r)
+	}
+}
+
+// Request handler for /channels/{name}/reports
+func HandleChannelReports(live *config.Live) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := live.Get().Config
+		dir, ok := channelDir(cfg, r.PathValue("name"))
+		if !ok {
+			http.Error(w, "Unknown channel", http.StatusNotFound)
+			return
+		}
+		handleReportRequests(dir, cfg.Server.CacheMaxAge.Duration, w, r)
+	}
+}
+
+// Request handler for /reports/{id}/provenance and its per-channel variant
+func HandleProvenance(live *config.Live) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := live.Get().Config
+		dir, ok := channelDir(cfg, r.PathValue("name"))
+		if !ok {
+			http.Error(w, "Unknown channel", http.StatusNotFound)
+			return
+		}
+
+		prov, err := FindProvenance(dir, r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Provenance not found", http.StatusNotFound)
+			return
+		}
+
+		body, err := json.Marshal(prov)
+		if err != nil {
+			http.Error(w, "Failed to encode provenance", http.StatusInternalServerError)
+			return
+		}
+		// A record only changes when its report is generated again
+		modified := prov.GeneratedAt.UTC().Truncate(time.Second)
+		if !cacheable(w, r, contentTag(body), modified, cfg.Server.CacheMaxAge.Duration) {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(append(body, '\n'))
 	}
 }
 
-// Request handler
-func HandleReportRequests(w http.ResponseWriter, r *http.Request) {
+// Resolves a channel's report dir, an empty name meaning the default channel
+func channelDir(cfg *config.Config, name string) (string, bool) {
+	if name == "" || name == config.DefaultChannel {
+		return cfg.Reports.Dir, true
+	}
+	channel, ok := cfg.Channel(name)
+	return channel.Dir, ok
+}
+
+func handleReportRequests(dir string, maxAge time.Duration, w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	index, _ := strconv.Atoi(r.URL.Query().Get("index"))
 	max, _ := strconv.Atoi(r.URL.Query().Get("max"))
@@ -27,12 +126,24 @@ func HandleReportRequests(w http.ResponseWriter, r *http.Request) {
 		max = 30
 	}
 
-	reports, err := loadReports(query, index, max, desiredRelevance)
-	if err != nil {
-		http.Error(w, "Failed to load reports", http.StatusInternalServerError)
+	// Expired reports are only searchable on request
+	archived := r.URL.Query().Get("archived") == "true"
+
+	store := IndexOf(dir)
+	if err := store.Err(); err != nil {
+		log.Printf("⚠️ Failed to serve reports from %s: %v", dir, err)
+		http.Error(w, "Failed to read reports", http.StatusInternalServerError)
+		return
+	}
+
+	// Identical queries against an unchanged store get a 304
+	version, modified := store.Version()
+	if !cacheable(w, r, etag(version, r.URL.Query()), modified, maxAge) {
 		return
 	}
 
+	reports := filterReports(store.Reports(archived), query, index, max, desiredRelevance)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(reports)
 }
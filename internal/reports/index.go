@@ -0,0 +1,397 @@
+package reports
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/renniemaharaj/news/internal/config"
+	"github.com/renniemaharaj/news/internal/types"
+)
+
+// EventKind says what happened to a report
+type EventKind string
+
+const (
+	EventSaved    EventKind = "saved"    // created or changed
+	EventArchived EventKind = "archived" // expired into the archive
+	EventDeleted  EventKind = "deleted"  // gone from both tiers
+)
+
+// Event describes one change to a store
+type Event struct {
+	Kind   EventKind
+	Dir    string // store the report belongs to
+	ID     string
+	Report types.Report // last known contents, also for deletions
+}
+
+// Index keeps a store's reports in memory. Writes through this package update
+// the entry they touch in place; WatchStores picks up external edits.
+type Index struct {
+	dir string
+
+	mu          sync.RWMutex
+	loaded      bool
+	err         error               // why the last sync could not list the store
+	tiers       [2]map[string]entry // live reports, then the archive
+	version     string              // hash of every file's stamp, stable across restarts
+	modified    time.Time           // when the store last changed
+	subscribers []chan Event
+}
+
+const (
+	tierLive = iota
+	tierArchive
+)
+
+type entry struct {
+	report types.Report
+	stamp  stamp
+}
+
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Buffered events per subscriber before new ones are dropped
+const subscriberBuffer = 64
+
+var (
+	indexesMu sync.Mutex
+	indexes   = map[string]*Index{}
+)
+
+// IndexOf returns the index of the store rooted at dir, loading it on first use
+func IndexOf(dir string) *Index {
+	key := storeKey(dir)
+
+	indexesMu.Lock()
+	x, ok := indexes[key]
+	if !ok {
+		x = &Index{dir: dir}
+		indexes[key] = x
+	}
+	indexesMu.Unlock()
+
+	x.mu.RLock()
+	loaded := x.loaded
+	x.mu.RUnlock()
+	if !loaded {
+		x.sync()
+	}
+	return x
+}
+
+// Reports returns a copy of every report in a tier, ordered by ID
+func (x *Index) Reports(archived bool) []types.Report {
+	tier := tierLive
+	if archived {
+		tier = tierArchive
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	ids := make([]string, 0, len(x.tiers[tier]))
+	for id := range x.tiers[tier] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	reports := make([]types.Report, len(ids))
+	for i, id := range ids {
+		reports[i] = x.tiers[tier][id].report
+	}
+	return reports
+}
+
+// Get returns the report with id from either tier
+func (x *Index) Get(id string) (types.Report, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	for _, tier := range x.tiers {
+		if e, ok := tier[id]; ok {
+			return e.report, true
+		}
+	}
+	return types.Report{}, false
+}
+
+// Err returns why the store could not be listed on the last sync, in which
+// case the index still holds the last reports it could read
+func (x *Index) Err() error {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.err
+}
+
+// Version identifies the store's current contents, and modified is when they
+// last changed, for HTTP cache validation
+func (x *Index) Version() (version string, modified time.Time) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.version, x.modified
+}
+
+// Subscribe returns a channel receiving every change to the store, and a
+// func to stop receiving. Slow subscribers miss events rather than block.
+func (x *Index) Subscribe() (<-chan Event, func()) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	x.subscribers = append(x.subscribers, ch)
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			x.mu.Lock()
+			defer x.mu.Unlock()
+			for i, sub := range x.subscribers {
+				if sub == ch {
+					x.subscribers = append(x.subscribers[:i], x.subscribers[i+1:]...)
+					close(ch)
+					break
+				}
+			}
+		})
+	}
+}
+
+// WatchStores polls the store of every configured channel each interval and
+// picks up reports added, edited or removed outside this process. Polling
+// keeps us free of a file notification dependency and also works on network
+// and container volumes where notifications are unreliable. A poll only
+// re-reads files whose stamp changed, so it is cheap on a quiet store.
+func WatchStores(live *config.Live, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cfg := live.Get().Config
+		for _, name := range cfg.ChannelNames() {
+			channel, _ := cfg.Channel(name)
+			IndexOf(channel.Dir).poll()
+		}
+	}
+}
+
+// Syncs with the files on disk without observing a write in progress
+func (x *Index) poll() {
+	lock := storeLock(x.dir)
+	lock.RLock()
+	defer lock.RUnlock()
+	x.sync()
+}
+
+// Brings the index up to date with the files on disk, re-reading only the
+// files whose size or modification time changed, and notifies subscribers.
+// A tier that cannot be listed, or a file that cannot be read, keeps its last
+// known contents. Listing errors are returned and kept for Err.
+func (x *Index) sync() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	var next [2]map[string]entry
+	var events []Event
+	var errs []error
+	for tier, dir := range []string{x.dir, ArchiveDir(x.dir)} {
+		// Only top-level files: channel namespaces may live in subdirectories
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			log.Printf("⚠️ Failed to read reports dir %s: %v", dir, err)
+			errs = append(errs, err)
+			next[tier] = x.tiers[tier]
+			continue
+		}
+
+		next[tier] = map[string]entry{}
+		for _, d := range entries {
+			if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+				continue
+			}
+			info, err := d.Info()
+			if err != nil {
+				continue // removed meanwhile
+			}
+
+			id := strings.TrimSuffix(d.Name(), ".json")
+			st := stamp{modTime: info.ModTime(), size: info.Size()}
+			old, known := x.tiers[tier][id]
+			if known && old.stamp == st {
+				next[tier][id] = old
+				continue
+			}
+
+			report, err := readReport(filepath.Join(dir, d.Name()))
+			if err != nil {
+				log.Printf("⚠️  Failed to unmarshal %s: %v", d.Name(), err)
+				if known {
+					next[tier][id] = old // retried on the next sync, its stamp still differs
+				}
+				continue
+			}
+			next[tier][id] = entry{report: report, stamp: st}
+			if tier == tierLive {
+				events = append(events, Event{Kind: EventSaved, Dir: x.dir, ID: id, Report: report})
+			}
+		}
+	}
+
+	for id, old := range x.tiers[tierLive] {
+		if _, ok := next[tierLive][id]; ok {
+			continue
+		}
+		if archived, ok := next[tierArchive][id]; ok {
+			events = append(events, Event{Kind: EventArchived, Dir: x.dir, ID: id, Report: archived.report})
+		} else {
+			events = append(events, Event{Kind: EventDeleted, Dir: x.dir, ID: id, Report: old.report})
+		}
+	}
+	for id, old := range x.tiers[tierArchive] {
+		_, live := x.tiers[tierLive][id]
+		if _, ok := next[tierArchive][id]; !ok && !live {
+			events = append(events, Event{Kind: EventDeleted, Dir: x.dir, ID: id, Report: old.report})
+		}
+	}
+
+	x.tiers = next
+	x.err = errors.Join(errs...)
+	x.changed(events)
+	return x.err
+}
+
+// Records a report just written to path in tier. Must hold the store lock.
+func (x *Index) put(tier int, id, path string, report types.Report) {
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Printf("⚠️ Failed to index %s: %v", path, err)
+		return // the next poll catches up
+	}
+	report.Provenance = nil // stored separately, as a read from disk would see it
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.tiers[tier] == nil {
+		x.tiers[tier] = map[string]entry{}
+	}
+	x.tiers[tier][id] = entry{report: report, stamp: stamp{modTime: info.ModTime(), size: info.Size()}}
+
+	var events []Event
+	if tier == tierLive {
+		events = append(events, Event{Kind: EventSaved, Dir: x.dir, ID: id, Report: report})
+	}
+	x.changed(events)
+}
+
+// Records a report just moved into the archive. Must hold the store lock.
+func (x *Index) archive(id string) {
+	info, err := os.Stat(filepath.Join(ArchiveDir(x.dir), id+".json"))
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	e, ok := x.tiers[tierLive][id]
+	if !ok {
+		return
+	}
+	delete(x.tiers[tierLive], id)
+	if err == nil {
+		if x.tiers[tierArchive] == nil {
+			x.tiers[tierArchive] = map[string]entry{}
+		}
+		e.stamp = stamp{modTime: info.ModTime(), size: info.Size()}
+		x.tiers[tierArchive][id] = e
+	}
+	x.changed([]Event{{Kind: EventArchived, Dir: x.dir, ID: id, Report: e.report}})
+}
+
+// Records a report just deleted from tier. Must hold the store lock.
+func (x *Index) remove(tier int, id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	e, ok := x.tiers[tier][id]
+	if !ok {
+		return
+	}
+	delete(x.tiers[tier], id)
+
+	var events []Event
+	if _, other := x.tiers[1-tier][id]; !other {
+		events = append(events, Event{Kind: EventDeleted, Dir: x.dir, ID: id, Report: e.report})
+	}
+	x.changed(events)
+}
+
+// Refreshes the version after a change and notifies subscribers of events.
+// Must hold x.mu.
+func (x *Index) changed(events []Event) {
+	if version, latest := x.fingerprint(); version != x.version {
+		x.version = version
+		if x.loaded {
+			latest = time.Now() // removals leave no modification time behind
+		}
+		x.modified = latest.UTC().Truncate(time.Second)
+	}
+
+	if !x.loaded {
+		x.loaded = true
+		return // the initial load is not a change
+	}
+
+	for _, event := range events {
+		for _, ch := range x.subscribers {
+			select {
+			case ch <- event:
+			default:
+				log.Printf("⚠️ Dropping %s event for %s, subscriber is not keeping up", event.Kind, event.ID)
+			}
+		}
+	}
+}
+
+// Hashes the stamp of every indexed file and finds the latest modification
+func (x *Index) fingerprint() (string, time.Time) {
+	h := sha256.New()
+	var latest time.Time
+	for tier, entries := range x.tiers {
+		ids := make([]string, 0, len(entries))
+		for id := range entries {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			st := entries[id].stamp
+			fmt.Fprintf(h, "%d/%s/%d/%d\n", tier, id, st.modTime.UnixNano(), st.size)
+			if st.modTime.After(latest) {
+				latest = st.modTime
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8]), latest
+}
+
+func readReport(path string) (types.Report, error) {
+	var report types.Report
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return report, err
+	}
+	err = json.Unmarshal(data, &report)
+	return report, err
+}
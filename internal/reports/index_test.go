@@ -0,0 +1,168 @@
+package reports
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/renniemaharaj/news/internal/types"
+)
+
+func writeTestReport(t *testing.T, dir, title string) {
+	t.Helper()
+	data, _ := json.Marshal(types.Report{Title: title, Date: "2026-10-19T00:00:00Z"})
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, sanitizeFilename(title)+".json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Drains the events already delivered to ch
+func drain(ch <-chan Event) []Event {
+	var events []Event
+	for {
+		select {
+		case event := <-ch:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestIndexSync(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(t *testing.T, dir string)
+		wantLive  int
+		wantKinds []EventKind
+		wantErr   bool
+	}{
+		{
+			name:     "unchanged",
+			change:   func(t *testing.T, dir string) {},
+			wantLive: 2,
+		},
+		{
+			name:      "added",
+			change:    func(t *testing.T, dir string) { writeTestReport(t, dir, "Gamma") },
+			wantLive:  3,
+			wantKinds: []EventKind{EventSaved},
+		},
+		{
+			name: "archived",
+			change: func(t *testing.T, dir string) {
+				os.MkdirAll(ArchiveDir(dir), 0755)
+				os.Rename(filepath.Join(dir, "alpha.json"), filepath.Join(ArchiveDir(dir), "alpha.json"))
+			},
+			wantLive:  1,
+			wantKinds: []EventKind{EventArchived},
+		},
+		{
+			name:      "deleted",
+			change:    func(t *testing.T, dir string) { os.Remove(filepath.Join(dir, "alpha.json")) },
+			wantLive:  1,
+			wantKinds: []EventKind{EventDeleted},
+		},
+		{
+			name: "unreadable file keeps its entry",
+			change: func(t *testing.T, dir string) {
+				os.WriteFile(filepath.Join(dir, "alpha.json"), []byte(`{"title": `), 0644)
+			},
+			wantLive: 2,
+		},
+		{
+			name: "unlistable store keeps its tier",
+			change: func(t *testing.T, dir string) {
+				os.RemoveAll(dir)
+				os.WriteFile(dir, nil, 0644) // listing a file fails
+			},
+			wantLive: 2,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "store")
+			writeTestReport(t, dir, "Alpha")
+			writeTestReport(t, dir, "Beta")
+
+			x := IndexOf(dir)
+			events, unsubscribe := x.Subscribe()
+			defer unsubscribe()
+			version, _ := x.Version()
+
+			tt.change(t, dir)
+			err := x.sync()
+			if (err != nil) != tt.wantErr || (x.Err() != nil) != tt.wantErr {
+				t.Fatalf("sync = %v, Err = %v, want error %v", err, x.Err(), tt.wantErr)
+			}
+			if got := len(x.Reports(false)); got != tt.wantLive {
+				t.Errorf("%d live reports, want %d", got, tt.wantLive)
+			}
+
+			got := drain(events)
+			if len(got) != len(tt.wantKinds) {
+				t.Fatalf("events %v, want kinds %v", got, tt.wantKinds)
+			}
+			for i, event := range got {
+				if event.Kind != tt.wantKinds[i] {
+					t.Errorf("event %d is %s, want %s", i, event.Kind, tt.wantKinds[i])
+				}
+			}
+			if changed, _ := x.Version(); len(tt.wantKinds) > 0 && changed == version {
+				t.Errorf("version unchanged after %v", tt.wantKinds)
+			}
+		})
+	}
+}
+
+func TestIndexInPlace(t *testing.T) {
+	dir := t.TempDir()
+	x := IndexOf(dir)
+	events, unsubscribe := x.Subscribe()
+	defer unsubscribe()
+
+	report := types.Report{Title: "Alpha", Date: "2026-10-19T00:00:00Z", Provenance: &types.Provenance{Stage: "report"}}
+	if _, err := writeReport(dir, report); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := x.Get("alpha"); !ok || got.Provenance != nil {
+		t.Fatalf("after save: Get = %+v, %v", got, ok)
+	}
+
+	lock := storeLock(dir)
+	lock.Lock()
+	if err := archiveReport(dir, "alpha"); err != nil {
+		t.Fatal(err)
+	}
+	x.archive("alpha")
+	if err := os.Remove(filepath.Join(ArchiveDir(dir), "alpha.json")); err != nil {
+		t.Fatal(err)
+	}
+	x.remove(tierArchive, "alpha")
+	lock.Unlock()
+
+	want := []EventKind{EventSaved, EventArchived, EventDeleted}
+	got := drain(events)
+	if len(got) != len(want) {
+		t.Fatalf("events %v, want kinds %v", got, want)
+	}
+	for i, event := range got {
+		if event.Kind != want[i] || event.ID != "alpha" {
+			t.Errorf("event %d is %s %s, want %s alpha", i, event.Kind, event.ID, want[i])
+		}
+	}
+
+	// Nothing left for a poll to find
+	before, _ := x.Version()
+	time.Sleep(10 * time.Millisecond)
+	x.sync()
+	if after, _ := x.Version(); after != before || len(drain(events)) > 0 {
+		t.Errorf("poll after in-place updates changed the index")
+	}
+}
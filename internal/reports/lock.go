@@ -0,0 +1,62 @@
+package reports
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Every store directory has one lock. Writers hold it exclusively, which also
+// serializes writes to the same report, and readers share it, so a read sees
+// either all or none of a save, archive or prune.
+var (
+	locksMu sync.Mutex
+	locks   = map[string]*sync.RWMutex{}
+)
+
+// Identifies a store however its dir is spelled
+func storeKey(dir string) string {
+	key := filepath.Clean(dir)
+	if abs, err := filepath.Abs(key); err == nil {
+		key = abs
+	}
+	return key
+}
+
+// Returns the lock of the store rooted at dir
+func storeLock(dir string) *sync.RWMutex {
+	key := storeKey(dir)
+
+	locksMu.Lock()
+	defer locksMu.Unlock()
+
+	lock, ok := locks[key]
+	if !ok {
+		lock = &sync.RWMutex{}
+		locks[key] = lock
+	}
+	return lock
+}
+
+// Writes data to path through a temp file in the same directory and a
+// rename, so readers never see a half-written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,89 @@
+package reports
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, path string) // prepares what is at path before the write
+		target   string                          // path relative to the temp dir
+		wantErr  bool
+		wantData string // contents of target afterwards, empty to skip
+		keep     string // file under target that must survive a failed write
+	}{
+		{
+			name:     "new file",
+			target:   "report.json",
+			wantData: "new",
+		},
+		{
+			name:   "replaces existing",
+			target: "report.json",
+			setup: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantData: "new",
+		},
+		{
+			name:   "rename fails",
+			target: "report.json",
+			setup: func(t *testing.T, path string) {
+				// A non-empty directory cannot be replaced by a rename
+				if err := os.MkdirAll(path, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(path, "original"), []byte("old"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+			keep:    "original",
+		},
+		{
+			name:    "missing directory",
+			target:  filepath.Join("missing", "report.json"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tt.target)
+			if tt.setup != nil {
+				tt.setup(t, path)
+			}
+
+			err := writeFileAtomic(path, []byte("new"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeFileAtomic = %v, want error %v", err, tt.wantErr)
+			}
+
+			if tt.wantData != "" {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != tt.wantData {
+					t.Errorf("contents %q, want %q", data, tt.wantData)
+				}
+			}
+			if tt.keep != "" {
+				data, err := os.ReadFile(filepath.Join(path, tt.keep))
+				if err != nil || string(data) != "old" {
+					t.Errorf("original changed by a failed write: %q, %v", data, err)
+				}
+			}
+
+			temps, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".*.tmp"))
+			if len(temps) > 0 {
+				t.Errorf("temp files left behind: %v", temps)
+			}
+		})
+	}
+}
@@ -2,35 +2,33 @@ package reports
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/renniemaharaj/news/internal/config"
 )
 
-var allowedOrigins = []string{
-	"http://localhost:5173",
-	"https://www.thewriterco.com",
-	"https://thewriterco.com",
-	"thewriterco.pages.dev",
-}
+// CORSMiddleware applies the CORS policy of the current config
+func CORSMiddleware(live *config.Live, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := &live.Get().Config.Server.CORS
 
-func isAllowedOrigin(origin string) bool {
-	for _, o := range allowedOrigins {
-		if o == origin {
-			return true
-		}
-	}
-	return false
-}
+		// The response depends on the Origin header whether or not it is allowed
+		w.Header().Add("Vary", "Origin")
 
-func CORSMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
-		if isAllowedOrigin(origin) {
+		allowed := policy.Allows(origin)
+		if allowed {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 		}
 
 		// Handle preflight request
-		if r.Method == http.MethodOptions {
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Methods", strings.Join(policy.Methods, ", "))
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(policy.Headers, ", "))
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+			}
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
@@ -14,150 +14,238 @@ import (
 	"github.com/renniemaharaj/news/internal/coordinator"
 
 	"github.com/renniemaharaj/news/internal/types"
-)
 
-const (
-	reportsDir       = "./reports"
-	reportingHour    = 8
-	reportExpiration = 72 * time.Hour // 3 days
+	"github.com/renniemaharaj/news/pkg/pool"
 )
 
-// Counts current reports and logs
-func CountReports() int {
-	reports, err := loadReports("", 0, 0, 0)
-	if err != nil {
-		log.Println(fmt.Errorf("error loading reports: %v", err))
-	}
+// Counts current reports in dir and logs
+func CountReports(dir string) int {
+	reports := filterReports(IndexOf(dir).Reports(false), "", 0, 0, 0)
 	log.Printf("📊 Found %d reports", len(reports))
 
 	return len(reports)
 }
 
-// The daily report-scraping scheduler
-func DailyScheduler() {
+// The daily report-scraping scheduler, running each channel at its own
+// reporting hour and rescheduling whenever config is reloaded. Channels whose
+// hour passes while others are still scraping run as soon as those finish.
+func DailyScheduler(p *pool.Instance, live *config.Live) {
+	changes := live.Subscribe()
+	lastRun := map[string]time.Time{}
 	for {
+		cfg := live.Get().Config
 		now := time.Now()
-		nextRun := time.Date(now.Year(), now.Month(), now.Day(), reportingHour, 0, 0, 0, now.Location())
-		if now.After(nextRun) {
-			nextRun = nextRun.Add(24 * time.Hour)
+		for _, name := range cfg.ChannelNames() {
+			if _, ok := lastRun[name]; !ok {
+				lastRun[name] = now // new channels wait for their next hour
+			}
 		}
 
-		log.Printf("⌛ Next scraping scheduled for: %s", nextRun.Format(time.RFC1123))
-		time.Sleep(time.Until(nextRun))
-		ScrapeReports()
+		nextRun, due := nextRuns(cfg, now, lastRun)
+		if len(due) == 0 {
+			log.Println("⌛ No channels to schedule")
+			<-changes
+			continue
+		}
+
+		log.Printf("⌛ Next scraping of %s scheduled for: %s", strings.Join(due, ", "), nextRun.Format(time.RFC1123))
+		timer := time.NewTimer(time.Until(nextRun))
+		select {
+		case <-timer.C:
+			for _, name := range due {
+				lastRun[name] = nextRun
+				ScrapeReports(p, live, name)
+			}
+		case <-changes:
+			timer.Stop()
+		}
 	}
 }
 
-// Daily report-scraper scraper function
-func ScrapeReports() {
-	channel := make(chan types.Report)
+// Returns the earliest upcoming run and the channels due at that time. A
+// channel is due at its reporting hour on the first day after its last run,
+// which is in the past when the run is overdue.
+func nextRuns(cfg *config.Config, now time.Time, lastRun map[string]time.Time) (time.Time, []string) {
+	var earliest time.Time
+	var due []string
+
+	for _, name := range cfg.ChannelNames() {
+		channel, _ := cfg.Channel(name)
+		last, ok := lastRun[name]
+		if !ok {
+			last = now
+		}
+		last = last.In(now.Location())
+		run := time.Date(last.Year(), last.Month(), last.Day(), *channel.ReportingHour, 0, 0, 0, now.Location())
+		if !run.After(last) {
+			run = run.AddDate(0, 0, 1) // keeps the wall-clock hour across DST changes
+		}
 
-	cfg, err := config.Load("config.json")
-	if err != nil {
-		log.Printf("⚠️ Failed to load config: %s", err)
-		return
+		switch {
+		case earliest.IsZero() || run.Before(earliest):
+			earliest, due = run, []string{name}
+		case run.Equal(earliest):
+			due = append(due, name)
+		}
+	}
+	return earliest, due
+}
+
+// ScrapeOptions adjusts a single Scrape run
+type ScrapeOptions struct {
+	Keywords []config.Keyword // replace the channel's keywords when set
+	DryRun   bool             // collect reports without touching the store
+}
+
+// Report-scraper for a single channel, logging instead of returning errors
+func ScrapeReports(p *pool.Instance, live *config.Live, name string) {
+	if _, err := Scrape(p, live.Get(), name, ScrapeOptions{}); err != nil {
+		log.Printf("⚠️ Pipeline error in channel %s: %s", name, err)
+	}
+}
+
+// Scrape runs one channel's pipeline against snap and returns the reports it
+// produced. The whole run uses snap even if config is reloaded meanwhile.
+func Scrape(p *pool.Instance, snap *config.Snapshot, name string, opts ScrapeOptions) ([]types.Report, error) {
+	settings, ok := snap.Config.Channel(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown channel %q", name)
+	}
+	if len(opts.Keywords) > 0 {
+		settings.Keywords = opts.Keywords
 	}
+	log.Printf("📰 Scraping channel %s", name)
+
+	channel := make(chan types.Report)
+	done := make(chan []types.Report)
 
 	// Save goroutine reads reports
 	go func() {
+		var produced []types.Report
 		for report := range channel {
-			saveReport(report)
+			if !opts.DryRun {
+				saveReport(settings.Dir, report)
+			}
+			produced = append(produced, report)
 		}
+		done <- produced
 	}()
 
-	if err := os.MkdirAll(reportsDir, os.ModePerm); err != nil {
-		log.Printf("⚠️ Failed to create reports directory: %s", err)
+	if !opts.DryRun {
+		if err := os.MkdirAll(settings.Dir, os.ModePerm); err != nil {
+			log.Printf("⚠️ Failed to create reports directory: %s", err)
+		}
+		Prune(settings.Dir, settings)
 	}
 
-	cleanExpiredReports()
-
 	// Run coordinator pipeline (it will close the channel when done)
-	if err := coordinator.Run(cfg, channel); err != nil {
-		log.Printf("⚠️ Pipeline error: %s", err)
+	err := coordinator.Run(snap.Config, settings, p, snap.Instructions, channel)
+	return <-done, err
+}
+
+// SaveReport function normalizes and saves the report to reports directory
+func saveReport(dir string, report types.Report) {
+	filename, err := writeReport(dir, normalizeDate(report, time.Now()))
+	if err != nil {
+		log.Printf("⚠️ Failed to save report %s: %v", report.Title, err)
+		return
 	}
+
+	log.Printf("✔️ Report saved: %s", filename)
 }
 
-// SaveReport function saves the report to reports directory
-func saveReport(report types.Report) {
-	safeTitle := sanitizeFilename(report.Title)
-	filename := filepath.Join(reportsDir, fmt.Sprintf("%s.json", safeTitle))
+// Writes report and its provenance to dir under its ID, atomically, and
+// returns the file name
+func writeReport(dir string, report types.Report) (string, error) {
+	lock := storeLock(dir)
+	lock.Lock()
+	defer lock.Unlock()
+
+	filename := filepath.Join(dir, ID(report)+".json")
 
 	data, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
-		log.Printf("⚠️ Failed to marshal report %s: %v", report.Title, err)
-		return
+		return "", err
 	}
 
-	if err := os.WriteFile(filename, data, 0644); err != nil {
-		log.Printf("⚠️ Failed to write file %s: %v", filename, err)
-		return
+	if err := writeFileAtomic(filename, data); err != nil {
+		return "", err
 	}
 
-	log.Printf("✔️ Report saved: %s", filename)
+	// A record left by an earlier report with this ID would describe the wrong one
+	if report.Provenance != nil {
+		if err := writeProvenance(dir, ID(report), *report.Provenance); err != nil {
+			log.Printf("⚠️ Failed to write provenance of %s: %v", report.Title, err)
+		}
+	} else if err := os.Remove(provenancePath(dir, ID(report))); err != nil && !os.IsNotExist(err) {
+		log.Printf("⚠️ Failed to remove stale provenance of %s: %v", report.Title, err)
+	}
+
+	IndexOf(dir).put(tierLive, ID(report), filename, report)
+	return filename, nil
 }
 
-// Local cleanExpiredReports function loadsReports and removes expired ones
-func cleanExpiredReports() {
-	reports, err := loadReports("", 0, 0, -1) // Load all reports regardless of relevance
-	if err != nil {
-		log.Printf("⚠️ Failed to load reports: %v", err)
-		return
-	}
+// Prune moves reports past their expiration into the archive and deletes
+// archived reports past the channel's delete_after horizon for good. Dates
+// of reports stored before they were normalized on save are migrated first.
+func Prune(dir string, channel config.Channel) (archived, deleted int) {
+	lock := storeLock(dir)
+	lock.Lock()
+	defer lock.Unlock()
 
 	now := time.Now()
-	for _, report := range reports {
+	index := IndexOf(dir)
+	migrateDates(dir, index)
+
+	for _, report := range index.Reports(false) {
 		reportTime := parseDate(report.Date)
 		if reportTime.IsZero() {
 			log.Printf("⚠️ Invalid date in report %s: skipping expiration check", report.Title)
 			continue
 		}
 
-		if now.Sub(reportTime) > reportExpiration {
-			filename := filepath.Join(reportsDir, fmt.Sprintf("%s.json", sanitizeFilename(report.Title)))
-			if err := os.Remove(filename); err != nil {
-				log.Printf("⚠️ Failed to delete expired report %s: %v", filename, err)
+		if now.Sub(reportTime) > channel.ExpirationFor(report.Tags) {
+			if err := archiveReport(dir, ID(report)); err != nil {
+				log.Printf("⚠️ Failed to archive expired report %s: %v", report.Title, err)
 			} else {
-				log.Printf("⚠️ Expired report removed: %s", filename)
+				index.archive(ID(report))
+				log.Printf("🗄️ Expired report archived: %s", report.Title)
+				archived++
 			}
 		}
 	}
-}
 
-func loadReports(search string, index, max, desiredRelevance int) ([]types.Report, error) {
-	var matched []types.Report
-
-	err := filepath.WalkDir(reportsDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
-			return err
-		}
-
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
+	if channel.DeleteAfter.Duration <= 0 {
+		return archived, 0
+	}
 
-		var report types.Report
-		if err := json.Unmarshal(data, &report); err != nil {
-			log.Printf("⚠️  Failed to unmarshal %s: %v", path, err)
-			return nil
+	for _, report := range index.Reports(true) {
+		reportTime := parseDate(report.Date)
+		if reportTime.IsZero() || now.Sub(reportTime) <= channel.DeleteAfter.Duration {
+			continue
 		}
 
-		parsed := parseDate(report.Date)
-		if report.Date == "" || parsed.IsZero() {
-			now := time.Now().UTC().Format(time.RFC3339)
-			log.Printf("⚠️ Found missing or invalid date in %s. Updating to current time: %s", report.Title, now)
-			report.Date = now
-			saveReport(report)
+		filename := filepath.Join(ArchiveDir(dir), ID(report)+".json")
+		if err := os.Remove(filename); err != nil {
+			log.Printf("⚠️ Failed to delete archived report %s: %v", filename, err)
+			continue
 		}
+		os.Remove(provenancePath(dir, ID(report)))
+		index.remove(tierArchive, ID(report))
+		log.Printf("⚠️ Archived report deleted: %s", filename)
+		deleted++
+	}
+	return archived, deleted
+}
 
+// Selects the reports matching search with relevance above desiredRelevance,
+// newest first, then pages them from index. max 0 returns every match.
+func filterReports(reports []types.Report, search string, index, max, desiredRelevance int) []types.Report {
+	var matched []types.Report
+	for _, report := range reports {
 		if (search == "" || reportMatches(report, search)) && (report.Relevance > desiredRelevance) {
 			matched = append(matched, report)
 		}
-		return nil
-	})
-
-	if err != nil {
-		return nil, err
 	}
 
 	sort.SliceStable(matched, func(i, j int) bool {
@@ -181,7 +269,7 @@ func loadReports(search string, index, max, desiredRelevance int) ([]types.Repor
 
 	if index >= len(matched) {
 		log.Printf("⚠️ Requested index %d exceeds matched reports (%d)", index, len(matched))
-		return []types.Report{}, nil
+		return []types.Report{}
 	}
 
 	end := index + max
@@ -189,5 +277,5 @@ func loadReports(search string, index, max, desiredRelevance int) ([]types.Repor
 		end = len(matched)
 	}
 
-	return matched[index:end], nil
+	return matched[index:end]
 }
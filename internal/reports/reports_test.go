@@ -0,0 +1,150 @@
+package reports
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/renniemaharaj/news/internal/config"
+	"github.com/renniemaharaj/news/internal/types"
+)
+
+func TestNextRuns(t *testing.T) {
+	six, eight := 6, 8
+	cfg := config.Default()
+	cfg.Keywords = []config.Keyword{{Query: "news"}}
+	cfg.Reports.ReportingHour = six
+	cfg.Channels = map[string]config.Channel{
+		"world": {Keywords: []config.Keyword{{Query: "world"}}, ReportingHour: &eight},
+		"faith": {Keywords: []config.Keyword{{Query: "faith"}}, ReportingHour: &six},
+	}
+
+	day := func(d, h, m int) time.Time { return time.Date(2026, 10, d, h, m, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		lastRun map[string]time.Time
+		wantRun time.Time
+		wantDue []string
+	}{
+		{"before every hour", day(19, 5, 0), nil, day(19, 6, 0), []string{"default", "faith"}},
+		{"between hours", day(19, 7, 0), nil, day(19, 8, 0), []string{"world"}},
+		{"after every hour", day(19, 9, 0), nil, day(20, 6, 0), []string{"default", "faith"}},
+		{
+			// world's hour passed while the 06:00 channels were scraping
+			"overdue after a long run", day(19, 8, 30),
+			map[string]time.Time{"default": day(19, 6, 0), "faith": day(19, 6, 0), "world": day(18, 8, 0)},
+			day(19, 8, 0), []string{"world"},
+		},
+		{
+			"ran today", day(19, 8, 30),
+			map[string]time.Time{"default": day(19, 6, 0), "faith": day(19, 6, 0), "world": day(19, 8, 0)},
+			day(20, 6, 0), []string{"default", "faith"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, due := nextRuns(&cfg, tt.now, tt.lastRun)
+			if !run.Equal(tt.wantRun) || !slices.Equal(due, tt.wantDue) {
+				t.Errorf("nextRuns = %s %v, want %s %v", run, due, tt.wantRun, tt.wantDue)
+			}
+		})
+	}
+}
+
+func TestNextRunsDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone data:", err)
+	}
+	cfg := config.Default()
+	cfg.Keywords = []config.Keyword{{Query: "news"}}
+	cfg.Reports.ReportingHour = 6
+
+	// Clocks fall back on 2026-11-01, a 25 hour day
+	now := time.Date(2026, 10, 31, 7, 0, 0, 0, loc)
+	run, _ := nextRuns(&cfg, now, nil)
+	if want := time.Date(2026, 11, 1, 6, 0, 0, 0, loc); !run.Equal(want) {
+		t.Errorf("nextRuns = %s, want %s", run, want)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	day := 24 * time.Hour
+	stored := []struct {
+		title string
+		age   time.Duration
+		tags  []string
+	}{
+		{"Fresh", day, nil},
+		{"Expired", 5 * day, nil},
+		{"Feature", 5 * day, []string{"breaking", "feature"}}, // the longest tag keeps it live
+		{"Breaking", 12 * time.Hour, []string{"breaking"}},
+		{"Ancient", 40 * day, nil},
+	}
+
+	tests := []struct {
+		name         string
+		deleteAfter  time.Duration
+		wantArchived int
+		wantDeleted  int
+		wantLive     []string
+		wantArchive  []string
+	}{
+		{"archive then delete", 30 * day, 3, 1, []string{"Feature", "Fresh"}, []string{"Breaking", "Expired"}},
+		{"never delete", 0, 3, 0, []string{"Feature", "Fresh"}, []string{"Ancient", "Breaking", "Expired"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			now := time.Now()
+			for _, r := range stored {
+				report := types.Report{
+					Title:      r.title,
+					Date:       now.Add(-r.age).UTC().Format(time.RFC3339),
+					Tags:       r.tags,
+					Provenance: &types.Provenance{Stage: "report"},
+				}
+				if _, err := writeReport(dir, report); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			channel := config.Channel{
+				Expiration:    config.Duration{Duration: 3 * day},
+				TagExpiration: map[string]config.Duration{"breaking": {Duration: 6 * time.Hour}, "feature": {Duration: 10 * day}},
+				DeleteAfter:   config.Duration{Duration: tt.deleteAfter},
+			}
+			archived, deleted := Prune(dir, channel)
+			if archived != tt.wantArchived || deleted != tt.wantDeleted {
+				t.Errorf("Prune = %d archived, %d deleted, want %d, %d", archived, deleted, tt.wantArchived, tt.wantDeleted)
+			}
+
+			for _, tier := range []struct {
+				dir  string
+				want []string
+			}{{dir, tt.wantLive}, {ArchiveDir(dir), tt.wantArchive}} {
+				matches, _ := filepath.Glob(filepath.Join(tier.dir, "*.json"))
+				var got []string
+				for _, m := range matches {
+					got = append(got, filepath.Base(m))
+				}
+				var want []string
+				for _, title := range tier.want {
+					want = append(want, ID(types.Report{Title: title})+".json")
+				}
+				if !slices.Equal(got, want) {
+					t.Errorf("%s holds %v, want %v", tier.dir, got, want)
+				}
+			}
+
+			_, err := os.Stat(provenancePath(dir, ID(types.Report{Title: "Ancient"})))
+			if gone := os.IsNotExist(err); gone != (tt.wantDeleted > 0) {
+				t.Errorf("provenance of the deleted report removed = %v, want %v", gone, tt.wantDeleted > 0)
+			}
+		})
+	}
+}
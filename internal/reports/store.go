@@ -0,0 +1,144 @@
+package reports
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/renniemaharaj/news/internal/types"
+)
+
+// ID is the identifier of a report in its store, derived from its title
+func ID(report types.Report) string {
+	return sanitizeFilename(report.Title)
+}
+
+// List returns the reports in dir, or its archive, matching search with
+// relevance above minRelevance, newest first. max 0 returns every match.
+// It fails while the store cannot be read.
+func List(dir string, archived bool, search string, index, max, minRelevance int) ([]types.Report, error) {
+	store := IndexOf(dir)
+	if err := store.Err(); err != nil {
+		return nil, err
+	}
+	return filterReports(store.Reports(archived), search, index, max, minRelevance), nil
+}
+
+// Find returns the report with id from dir, or else from its archive
+func Find(dir, id string) (types.Report, error) {
+	if id == "" || id != sanitizeFilename(id) {
+		return types.Report{}, fmt.Errorf("invalid report id %q", id)
+	}
+
+	report, ok := IndexOf(dir).Get(id)
+	if !ok {
+		return report, fmt.Errorf("report %s not found", id)
+	}
+	return report, nil
+}
+
+// Export writes every report in dir to w as one JSON array
+func Export(dir string, w io.Writer) (int, error) {
+	reports, err := List(dir, false, "", 0, 0, -1)
+	if err != nil {
+		return 0, err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return len(reports), enc.Encode(reports)
+}
+
+// Import reads a JSON array of reports, as written by Export, into dir,
+// normalizing their dates. Reports with the same ID are overwritten.
+func Import(dir string, r io.Reader) (int, error) {
+	var reports []types.Report
+	if err := json.NewDecoder(r).Decode(&reports); err != nil {
+		return 0, fmt.Errorf("failed to decode reports: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for i, report := range reports {
+		if ID(report) == "" {
+			return i, fmt.Errorf("report %d has no usable title", i)
+		}
+		if _, err := writeReport(dir, normalizeDate(report, now)); err != nil {
+			return i, err
+		}
+	}
+	return len(reports), nil
+}
+
+// MigrateDates normalizes the dates of reports in dir stored before dates
+// were normalized on save, and returns how many it rewrote
+func MigrateDates(dir string) int {
+	lock := storeLock(dir)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return migrateDates(dir, IndexOf(dir))
+}
+
+// Expired reports are archived to a directory that can never be a channel name
+const archiveDir = ".archive"
+
+// ArchiveDir is where the expired reports of the store in dir are kept
+func ArchiveDir(dir string) string {
+	return filepath.Join(dir, archiveDir)
+}
+
+// Moves a report into the archive, keeping its provenance where it is
+func archiveReport(dir, id string) error {
+	if err := os.MkdirAll(ArchiveDir(dir), os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(dir, id+".json"), filepath.Join(ArchiveDir(dir), id+".json"))
+}
+
+// Provenance records live beside the reports in a directory that can never
+// be a channel name
+const provenanceDir = ".provenance"
+
+func provenancePath(dir, id string) string {
+	return filepath.Join(dir, provenanceDir, id+".json")
+}
+
+func writeProvenance(dir, id string, prov types.Provenance) error {
+	if err := os.MkdirAll(filepath.Join(dir, provenanceDir), os.ModePerm); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(prov, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(provenancePath(dir, id), data)
+}
+
+// FindProvenance returns the provenance record of the report with id
+func FindProvenance(dir, id string) (types.Provenance, error) {
+	var prov types.Provenance
+	if id == "" || id != sanitizeFilename(id) {
+		return prov, fmt.Errorf("invalid report id %q", id)
+	}
+
+	lock := storeLock(dir)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	data, err := os.ReadFile(provenancePath(dir, id))
+	if err != nil {
+		return prov, err
+	}
+	if err := json.Unmarshal(data, &prov); err != nil {
+		return prov, fmt.Errorf("failed to unmarshal provenance %s: %w", id, err)
+	}
+	return prov, nil
+}
@@ -0,0 +1,150 @@
+package reports
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renniemaharaj/news/internal/types"
+)
+
+func TestWriteReportProvenance(t *testing.T) {
+	tests := []struct {
+		name     string
+		second   *types.Provenance
+		wantProv bool
+	}{
+		{"replaced", &types.Provenance{Stage: "repair"}, true},
+		{"stale record removed", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			report := types.Report{Title: "Alpha", Date: "2026-10-19T00:00:00Z", Provenance: &types.Provenance{Stage: "report"}}
+			if _, err := writeReport(dir, report); err != nil {
+				t.Fatal(err)
+			}
+
+			report.Provenance = tt.second
+			if _, err := writeReport(dir, report); err != nil {
+				t.Fatal(err)
+			}
+
+			prov, err := FindProvenance(dir, ID(report))
+			if (err == nil) != tt.wantProv {
+				t.Fatalf("FindProvenance = %+v, %v, want a record %v", prov, err, tt.wantProv)
+			}
+			if tt.wantProv && prov.Stage != tt.second.Stage {
+				t.Errorf("provenance stage %q, want %q", prov.Stage, tt.second.Stage)
+			}
+		})
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	for _, report := range []types.Report{
+		{Title: "Alpha", Date: "2026-10-18T09:00:00Z", Relevance: 7, Tags: []string{"politics"}, Images: []string{"https://example.com/a.jpg"}},
+		{Title: "Beta", Date: "2026-10-17T09:00:00Z", Relevance: 3, URL: "https://example.com/beta", DateOriginal: "yesterday"},
+		{Title: "Gamma: 50% off?", Date: "2026-10-16T09:00:00Z", Model: "gemini-2.0-flash", Repaired: []string{"date"}},
+	} {
+		if _, err := writeReport(src, report); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	exported, err := Export(src, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := Import(dst, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported != 3 || imported != exported {
+		t.Fatalf("exported %d, imported %d, want 3 each", exported, imported)
+	}
+
+	want, _ := List(src, false, "", 0, 0, -1)
+	got, err := List(dst, false, "", 0, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip changed reports:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestImportMalformed(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantN     int
+		wantErr   string
+		wantTitle []string // titles stored afterwards
+	}{
+		{"empty array", `[]`, 0, "", nil},
+		{"not json", `reports`, 0, "failed to decode reports", nil},
+		{"object instead of array", `{"title": "Alpha"}`, 0, "failed to decode reports", nil},
+		{"wrong field type", `[{"title": 5}]`, 0, "failed to decode reports", nil},
+		{"truncated", `[{"title": "Alpha"}, {"title": "Be`, 0, "failed to decode reports", nil},
+		{"untitled report stops the import", `[{"title": "Alpha"}, {"title": "???"}, {"title": "Gamma"}]`, 1, "report 1 has no usable title", []string{"Alpha"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			n, err := Import(dir, strings.NewReader(tt.input))
+			if n != tt.wantN {
+				t.Errorf("imported %d, want %d", n, tt.wantN)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Import = %v, want %q", err, tt.wantErr)
+			}
+
+			stored, err := List(dir, false, "", 0, 0, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var titles []string
+			for _, report := range stored {
+				titles = append(titles, report.Title)
+			}
+			if !slices.Equal(titles, tt.wantTitle) {
+				t.Errorf("stored %q, want %q", titles, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestImportNormalizesDates(t *testing.T) {
+	input := `[{"title": "Alpha", "date": "2026-10-18"}, {"title": "Beta", "date": "someday"}]`
+	dir := t.TempDir()
+	if _, err := Import(dir, strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		title, original string
+	}{
+		{"Alpha", "2026-10-18"},
+		{"Beta", "someday"},
+	} {
+		report, err := Find(dir, ID(types.Report{Title: tt.title}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := time.Parse(time.RFC3339, report.Date); err != nil {
+			t.Errorf("%s date %q is not RFC 3339", tt.title, report.Date)
+		}
+		if report.DateOriginal != tt.original {
+			t.Errorf("%s original date %q, want %q", tt.title, report.DateOriginal, tt.original)
+		}
+	}
+}
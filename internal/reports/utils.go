@@ -1,11 +1,15 @@
 package reports
 
 import (
+	"encoding/json"
 	"log"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/renniemaharaj/news/internal/dates"
 	"github.com/renniemaharaj/news/internal/types"
 )
 
@@ -34,14 +38,73 @@ func sanitizeFilename(name string) string {
 	return re.ReplaceAllString(name, "")
 }
 
+// Parses a stored date, zero when missing or not RFC 3339. Reads never
+// resolve or repair: dates are normalized once, when a report is saved, and
+// older files are migrated by Prune.
 func parseDate(dateStr string) time.Time {
-	if dateStr == "" {
-		return time.Time{}
-	}
 	t, err := time.Parse(time.RFC3339, dateStr)
 	if err != nil {
-		log.Printf("⚠️ Failed to parse date '%s': %v", dateStr, err)
 		return time.Time{}
 	}
 	return t
 }
+
+// Migrates the dates of every report in both tiers of the store in dir,
+// returning how many were rewritten. Must hold the store lock.
+func migrateDates(dir string, index *Index) int {
+	migrated := 0
+	for tier, tierDir := range []string{dir, ArchiveDir(dir)} {
+		for _, report := range index.Reports(tier == tierArchive) {
+			path := filepath.Join(tierDir, ID(report)+".json")
+			if report, ok := migrateDate(path, report); ok {
+				index.put(tier, ID(report), path, report)
+				migrated++
+			}
+		}
+	}
+	return migrated
+}
+
+// Normalizes the date of a report stored before dates were normalized on
+// save, rewriting its file at path and returning the migrated report. Relative dates are resolved against the
+// file's modification time, when they were written. Must hold the store lock.
+func migrateDate(path string, report types.Report) (types.Report, bool) {
+	if _, err := time.Parse(time.RFC3339, report.Date); err == nil {
+		return report, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return report, false
+	}
+
+	migrated := normalizeDate(report, info.ModTime())
+	data, err := json.MarshalIndent(migrated, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(path, data)
+	}
+	if err != nil {
+		log.Printf("⚠️ Failed to migrate date of %s: %v", report.Title, err)
+		return report, false
+	}
+
+	log.Printf("🗓️ Migrated date of %s from %q to %s", report.Title, report.Date, migrated.Date)
+	return migrated, true
+}
+
+// Rewrites the report's date as an RFC 3339 UTC timestamp, keeping what the
+// model wrote. Unreadable dates fall back to now, the time the report was seen.
+func normalizeDate(report types.Report, now time.Time) types.Report {
+	normalized, err := dates.Normalize(report.Date, now)
+	if err != nil {
+		log.Printf("⚠️ Unreadable date %q in %s, using the current time", report.Date, report.Title)
+		normalized = now.UTC().Format(time.RFC3339)
+	}
+
+	if normalized != report.Date {
+		if report.DateOriginal == "" {
+			report.DateOriginal = report.Date
+		}
+		report.Date = normalized
+	}
+	return report
+}
@@ -0,0 +1,71 @@
+package reports
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/renniemaharaj/news/internal/types"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-10-19T08:30:00Z", time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)},
+		{"2026-10-19T08:30:00-04:00", time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)},
+		{"2026-10-19", time.Time{}},
+		{"2 hours ago", time.Time{}},
+		{"", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := parseDate(tt.in); !got.Equal(tt.want) {
+				t.Errorf("parseDate(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateDate(t *testing.T) {
+	written := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		date         string
+		wantMigrated bool
+		wantDate     string
+		wantOriginal string
+	}{
+		{"2026-10-01T09:00:00Z", false, "2026-10-01T09:00:00Z", ""},
+		{"2026-10-01", true, "2026-10-01T00:00:00Z", "2026-10-01"},
+		{"3 hours ago", true, "2026-10-01T09:00:00Z", "3 hours ago"},
+		{"whenever", true, "2026-10-01T12:00:00Z", "whenever"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "report.json")
+			report := types.Report{Title: "Report", Date: tt.date}
+			data, _ := json.Marshal(report)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, written, written); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, got := migrateDate(path, report); got != tt.wantMigrated {
+				t.Fatalf("migrateDate = %v, want %v", got, tt.wantMigrated)
+			}
+			stored, err := readReport(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Date != tt.wantDate || stored.DateOriginal != tt.wantOriginal {
+				t.Errorf("stored date %q (original %q), want %q (%q)", stored.Date, stored.DateOriginal, tt.wantDate, tt.wantOriginal)
+			}
+		})
+	}
+}
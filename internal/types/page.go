@@ -0,0 +1,32 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// Page is the scraped content of a single source URL
+type Page struct {
+	URL    string   `json:"url"`
+	Text   string   `json:"text"`
+	Images []string `json:"images"`
+
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// String renders the page as the plain-text block sent to the model
+func (p Page) String() string {
+	var sb strings.Builder
+	sb.WriteString(p.Text)
+
+	// Append the images at the bottom
+	if len(p.Images) > 0 {
+		sb.WriteString("\n\nimages:\n")
+		for _, img := range p.Images {
+			sb.WriteString(img + "\n")
+		}
+	}
+
+	sb.WriteString("\n\nsource_url=" + p.URL)
+	return sb.String()
+}
@@ -0,0 +1,35 @@
+package types
+
+import "time"
+
+// Provenance records how a report was produced, for editorial auditing
+type Provenance struct {
+	GeneratedAt      time.Time       `json:"generated_at"`
+	Sources          []Source        `json:"sources"`
+	Stage            string          `json:"stage"` // pipeline stage that produced the final report
+	Model            string          `json:"model"`
+	Key              string          `json:"key"` // fingerprint, never the key itself
+	Parameters       ModelParameters `json:"parameters"`
+	Profile          string          `json:"profile"`
+	InstructionHash  string          `json:"instruction_hash"` // version of the instruction template
+	Attempts         int             `json:"attempts"`         // model replies received across all stages
+	ValidationErrors []string        `json:"validation_errors"`
+	Cached           bool            `json:"cached,omitempty"`
+}
+
+// Source is a scraped page a report was generated from
+type Source struct {
+	URL         string    `json:"url"`
+	ContentHash string    `json:"content_hash"` // sha256 of the page as sent to the model
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// ModelParameters are the generation parameters a report was produced with
+type ModelParameters struct {
+	Temperature      float32 `json:"temperature"`
+	TopK             int32   `json:"top_k"`
+	TopP             float32 `json:"top_p"`
+	MaxOutputTokens  int32   `json:"max_output_tokens"`
+	ResponseMIMEType string  `json:"response_mime_type"`
+	Applied          string  `json:"applied"` // built-in set and overrides, e.g. builtin:x+stage:repair
+}
@@ -5,7 +5,13 @@ type Report struct {
 	Summary   string   `json:"summary"`
 	Tags      []string `json:"tags"`
 	URL       string   `json:"url"`
-	Date      string   `json:"date"`
+	Date      string   `json:"date"` // RFC 3339 UTC once stored
 	Relevance int      `json:"relevance"`
 	Images    []string `json:"images"`
+	Repaired  []string `json:"repaired,omitempty" schema:"-"` // fields corrected by a repair prompt
+	Model     string   `json:"model,omitempty" schema:"-"`    // model base that produced the report
+
+	DateOriginal string `json:"date_original,omitempty" schema:"-"` // date as the model wrote it, when normalized
+
+	Provenance *Provenance `json:"-" schema:"-"` // stored beside the report, not in it
 }
@@ -3,29 +3,206 @@ package validation
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/renniemaharaj/news/internal/dates"
 	"github.com/renniemaharaj/news/internal/types"
 )
 
-type ReportWrapper struct {
-	Reports []types.Report
+const (
+	minSummaryLength = 80
+	maxSummaryLength = 1500
+	// Tolerate date-only values from sources a timezone ahead of us
+	futureTolerance = 24 * time.Hour
+)
+
+// Violation describes one invalid field of one report
+type Violation struct {
+	Report  int
+	Field   string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("report %d: %s: %s", v.Report, v.Field, v.Message)
+}
+
+// Violations collects every problem found in a response so retry feedback is specific
+type Violations []Violation
+
+func (v Violations) Error() string {
+	lines := make([]string, len(v))
+	for i, violation := range v {
+		lines[i] = violation.String()
+	}
+	return "⚠️ invalid reports:\n" + strings.Join(lines, "\n")
 }
 
-func Validate(resp string) error {
-	var wrapper ReportWrapper
+// Validator checks model output against the pages scraped for that batch
+type Validator struct {
+	Sources []types.Page
+	Now     func() time.Time
+}
+
+// New returns a validator for reports generated from the given pages
+func New(sources []types.Page) *Validator {
+	return &Validator{Sources: sources, Now: time.Now}
+}
+
+// Validate unmarshals resp and returns all violations at once
+func (v *Validator) Validate(resp string) error {
+	var wrapper types.Wrapper
 	err := json.Unmarshal([]byte(resp), &wrapper)
 	if err != nil {
 		return err
 	}
 
+	var violations Violations
 	for i, report := range wrapper.Reports {
-		if report.Title == "" || report.Summary == "" {
-			return fmt.Errorf("⚠️ report %d has missing fields", i)
+		violations = append(violations, v.Report(i, report)...)
+	}
+
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
+
+// Partial accepts any response whose JSON decodes, leaving every invalid
+// report to be repaired or dropped individually instead of resending the batch
+func (v *Validator) Partial(resp string) error {
+	var wrapper types.Wrapper
+	return json.Unmarshal([]byte(resp), &wrapper)
+}
+
+// Fields lists the distinct fields named by the violations
+func (v Violations) Fields() []string {
+	seen := map[string]struct{}{}
+	var fields []string
+	for _, violation := range v {
+		if _, ok := seen[violation.Field]; ok {
+			continue
 		}
-		if report.Relevance < 1 || report.Relevance > 10 {
-			return fmt.Errorf("⚠️ report %d has invalid relevance: %d", i, report.Relevance)
+		seen[violation.Field] = struct{}{}
+		fields = append(fields, violation.Field)
+	}
+	return fields
+}
+
+// Report returns the violations of a single report at position i
+func (v *Validator) Report(i int, report types.Report) Violations {
+	var violations Violations
+	add := func(field, format string, args ...any) {
+		violations = append(violations, Violation{Report: i, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(report.Title) == "" {
+		add("title", "must not be empty")
+	}
+
+	switch n := utf8.RuneCountInString(strings.TrimSpace(report.Summary)); {
+	case n == 0:
+		add("summary", "must not be empty")
+	case n < minSummaryLength:
+		add("summary", "too short: %d characters, want at least %d", n, minSummaryLength)
+	case n > maxSummaryLength:
+		add("summary", "too long: %d characters, want at most %d", n, maxSummaryLength)
+	}
+
+	if report.Relevance < 1 || report.Relevance > 10 {
+		add("relevance", "must be between 1 and 10, got %d", report.Relevance)
+	}
+
+	if len(report.Tags) == 0 {
+		add("tags", "must not be empty")
+	}
+	seen := map[string]struct{}{}
+	for _, tag := range report.Tags {
+		key := strings.ToLower(strings.TrimSpace(tag))
+		if key == "" {
+			add("tags", "contains an empty tag")
+			continue
+		}
+		if _, dup := seen[key]; dup {
+			add("tags", "duplicate tag %q", tag)
 		}
+		seen[key] = struct{}{}
 	}
 
-	return nil
+	if date, err := dates.Parse(report.Date, v.now()); err != nil {
+		add("date", "%q is not a recognizable date, use ISO 8601 such as 2006-01-02", report.Date)
+	} else if date.After(v.now().Add(futureTolerance)) {
+		add("date", "%q is in the future", report.Date)
+	}
+
+	if len(v.Sources) == 0 {
+		return violations
+	}
+
+	source, found := v.source(report.URL)
+	if !found {
+		add("url", "%q is not one of the scraped source URLs", report.URL)
+	}
+
+	for _, img := range report.Images {
+		if !v.hasImage(source, found, img) {
+			add("images", "%q does not appear on the source page", img)
+		}
+	}
+
+	return violations
+}
+
+func (v *Validator) now() time.Time {
+	if v.Now == nil {
+		return time.Now()
+	}
+	return v.Now()
+}
+
+// SameURL reports whether two URLs name the same page, ignoring fragments,
+// trailing slashes and surrounding space
+func SameURL(a, b string) bool {
+	return normalizeURL(a) == normalizeURL(b)
+}
+
+func normalizeURL(u string) string {
+	u = strings.TrimSpace(u)
+	if i := strings.Index(u, "#"); i >= 0 {
+		u = u[:i]
+	}
+	return strings.TrimSuffix(u, "/")
+}
+
+// Finds the scraped page a report claims as its source
+func (v *Validator) source(url string) (types.Page, bool) {
+	want := normalizeURL(url)
+	if want == "" {
+		return types.Page{}, false
+	}
+	for _, page := range v.Sources {
+		if normalizeURL(page.URL) == want {
+			return page, true
+		}
+	}
+	return types.Page{}, false
+}
+
+// Images must come from the report's own page, or any page when the URL is unknown
+func (v *Validator) hasImage(source types.Page, found bool, img string) bool {
+	pages := v.Sources
+	if found {
+		pages = []types.Page{source}
+	}
+	want := normalizeURL(img)
+	for _, page := range pages {
+		for _, candidate := range page.Images {
+			if normalizeURL(candidate) == want {
+				return true
+			}
+		}
+	}
+	return false
 }
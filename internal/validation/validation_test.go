@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renniemaharaj/news/internal/types"
+)
+
+var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+func validReport() types.Report {
+	return types.Report{
+		Title:     "Cabinet sworn in",
+		Summary:   strings.Repeat("A summary of the article. ", 5),
+		Tags:      []string{"Politics"},
+		URL:       "https://example.com/a",
+		Date:      "2026-10-19",
+		Relevance: 5,
+	}
+}
+
+func TestPartial(t *testing.T) {
+	v := &Validator{Now: func() time.Time { return now }}
+
+	tests := []struct {
+		name    string
+		resp    string
+		wantErr bool
+	}{
+		{"not json", `reports: none`, true},
+		{"wrong shape", `{"reports": "none"}`, true},
+		{"empty", `{"reports": []}`, false},
+		{"only invalid report", `{"reports": [{"title": "x", "relevance": 11}]}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := v.Partial(tt.resp); (err != nil) != tt.wantErr {
+				t.Errorf("Partial(%s) = %v, want error %v", tt.resp, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	v := &Validator{
+		Sources: []types.Page{{URL: "https://example.com/a", Images: []string{"https://example.com/a.jpg"}}},
+		Now:     func() time.Time { return now },
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *types.Report)
+		fields []string
+	}{
+		{"valid", func(r *types.Report) {}, nil},
+		{"relevance out of range", func(r *types.Report) { r.Relevance = 11 }, []string{"relevance"}},
+		{"short summary", func(r *types.Report) { r.Summary = "too short" }, []string{"summary"}},
+		{"no tags", func(r *types.Report) { r.Tags = nil }, []string{"tags"}},
+		{"relative date", func(r *types.Report) { r.Date = "2 hours ago" }, nil},
+		{"unreadable date", func(r *types.Report) { r.Date = "soon" }, []string{"date"}},
+		{"future date", func(r *types.Report) { r.Date = "2026-10-25" }, []string{"date"}},
+		{"unknown source", func(r *types.Report) { r.URL = "https://elsewhere.com" }, []string{"url"}},
+		{"foreign image", func(r *types.Report) { r.Images = []string{"https://cdn.com/x.jpg"} }, []string{"images"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := validReport()
+			tt.modify(&report)
+
+			got := v.Report(0, report).Fields()
+			if strings.Join(got, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("fields = %v, want %v", got, tt.fields)
+			}
+		})
+	}
+}
+
+func TestSameURL(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"https://example.com/a", "https://example.com/a", true},
+		{"https://example.com/a/", "https://example.com/a", true},
+		{"https://example.com/a#top", "https://example.com/a", true},
+		{" https://example.com/a ", "https://example.com/a", true},
+		{"https://example.com/a", "https://example.com/b", false},
+		{"https://example.com/a?page=2", "https://example.com/a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := SameURL(tt.a, tt.b); got != tt.want {
+				t.Errorf("SameURL(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store is a content-addressed, on-disk cache of validated model responses
+type Store struct {
+	Dir string
+	TTL time.Duration
+}
+
+// Keys embed the rendered instruction, which changes daily, so most expired
+// entries are never read again. Put sweeps a directory at most this often.
+const sweepInterval = time.Hour
+
+var sweeps = struct {
+	sync.Mutex
+	last map[string]time.Time
+}{last: map[string]time.Time{}}
+
+type entry struct {
+	Base      string    `json:"base"`
+	CreatedAt time.Time `json:"created_at"`
+	Response  string    `json:"response"`
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// Key derives a cache key from the model base, system instruction and input
+func Key(base, instruction, input string) string {
+	return hash(base + "\x00" + hash(instruction) + "\x00" + hash(input))
+}
+
+func (s *Store) path(key string) string {
+	return filepath.Join(s.Dir, fmt.Sprintf("%s.json", key))
+}
+
+// Get returns a cached response if present and not expired
+func (s *Store) Get(key string) (string, bool) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return "", false
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		os.Remove(s.path(key))
+		return "", false
+	}
+
+	if s.TTL > 0 && time.Since(e.CreatedAt) > s.TTL {
+		os.Remove(s.path(key))
+		return "", false
+	}
+
+	return e.Response, true
+}
+
+// Put stores a validated response under key
+func (s *Store) Put(key, base, response string) error {
+	if key == "" {
+		return errors.New("empty cache key")
+	}
+	if err := os.MkdirAll(s.Dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry{Base: base, CreatedAt: time.Now().UTC(), Response: response})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file first so readers never observe a partial entry
+	tmp, err := os.CreateTemp(s.Dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path(key)); err != nil {
+		return err
+	}
+
+	if s.sweepDue(time.Now()) {
+		s.Sweep()
+	}
+	return nil
+}
+
+// Reports whether the directory was last swept over sweepInterval ago,
+// recording a sweep now if so
+func (s *Store) sweepDue(now time.Time) bool {
+	sweeps.Lock()
+	defer sweeps.Unlock()
+
+	dir := filepath.Clean(s.Dir)
+	if now.Sub(sweeps.last[dir]) < sweepInterval {
+		return false
+	}
+	sweeps.last[dir] = now
+	return true
+}
+
+// Sweep removes expired entries, judged by when their file was written, and
+// temp files left behind by interrupted writes. It returns how many files
+// it removed.
+func (s *Store) Sweep() (int, error) {
+	files, err := os.ReadDir(s.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	removed := 0
+	now := time.Now()
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil || f.IsDir() {
+			continue
+		}
+		age := now.Sub(info.ModTime())
+
+		stale := false
+		switch {
+		case strings.HasPrefix(f.Name(), "tmp-"):
+			stale = age > sweepInterval
+		case strings.HasSuffix(f.Name(), ".json"):
+			stale = s.TTL > 0 && age > s.TTL
+		}
+		if stale && os.Remove(filepath.Join(s.Dir, f.Name())) == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetPut(t *testing.T) {
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		age    time.Duration // how long ago the entry was written
+		put    bool
+		want   string
+		wantOK bool
+	}{
+		{"miss", time.Hour, 0, false, "", false},
+		{"hit", time.Hour, 0, true, "response", true},
+		{"no ttl never expires", 0, 1000 * time.Hour, true, "response", true},
+		{"fresh", time.Hour, 30 * time.Minute, true, "response", true},
+		{"expired", time.Hour, 2 * time.Hour, true, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Store{Dir: t.TempDir(), TTL: tt.ttl}
+			key := Key("flash", "instruction", "input")
+			if tt.put {
+				if err := s.Put(key, "flash", "response"); err != nil {
+					t.Fatal(err)
+				}
+				backdate(t, s, key, tt.age)
+			}
+
+			got, ok := s.Get(key)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Get = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+			if _, err := os.Stat(s.path(key)); tt.put && !tt.wantOK && !os.IsNotExist(err) {
+				t.Errorf("expired entry not removed on Get")
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	base := Key("flash", "instruction", "input")
+	for _, other := range []string{
+		Key("pro", "instruction", "input"),
+		Key("flash", "instruction 2", "input"),
+		Key("flash", "instruction", "input 2"),
+		Key("flashinstruction", "", "input"),
+	} {
+		if other == base {
+			t.Errorf("distinct inputs share key %s", base)
+		}
+	}
+	if err := (&Store{Dir: t.TempDir()}).Put("", "flash", "x"); err == nil {
+		t.Errorf("Put with an empty key succeeded")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	s := &Store{Dir: t.TempDir(), TTL: time.Hour}
+	files := []struct {
+		name     string
+		age      time.Duration
+		wantKept bool
+	}{
+		{"fresh.json", time.Minute, true},
+		{"expired.json", 2 * time.Hour, false},
+		{"tmp-recent", time.Minute, true}, // may be a write in progress
+		{"tmp-abandoned", 2 * time.Hour, false},
+		{"notes.txt", 2 * time.Hour, true},
+	}
+	for _, f := range files {
+		path := filepath.Join(s.Dir, f.name)
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mod := time.Now().Add(-f.age)
+		os.Chtimes(path, mod, mod)
+	}
+
+	removed, err := s.Sweep()
+	if err != nil || removed != 2 {
+		t.Fatalf("Sweep = %d, %v, want 2 removed", removed, err)
+	}
+	for _, f := range files {
+		_, err := os.Stat(filepath.Join(s.Dir, f.name))
+		if kept := err == nil; kept != f.wantKept {
+			t.Errorf("%s kept = %v, want %v", f.name, kept, f.wantKept)
+		}
+	}
+}
+
+func TestPutSweepsExpired(t *testing.T) {
+	s := &Store{Dir: t.TempDir(), TTL: time.Hour}
+	old := Key("flash", "yesterday's instruction", "input")
+	if err := s.Put(old, "flash", "old"); err != nil {
+		t.Fatal(err)
+	}
+	backdate(t, s, old, 2*time.Hour)
+
+	// Due again as if the last sweep was long ago
+	sweeps.Lock()
+	sweeps.last[filepath.Clean(s.Dir)] = time.Time{}
+	sweeps.Unlock()
+
+	if err := s.Put(Key("flash", "today's instruction", "input"), "flash", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(s.path(old)); !os.IsNotExist(err) {
+		t.Errorf("expired entry survived a sweeping Put")
+	}
+	if s.sweepDue(time.Now().Add(sweepInterval / 2)) {
+		t.Errorf("sweep due again before sweepInterval")
+	}
+}
+
+// Makes the entry for key look written age ago
+func backdate(t *testing.T, s *Store, key string, age time.Duration) {
+	t.Helper()
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		t.Fatal(err)
+	}
+	e.CreatedAt = e.CreatedAt.Add(-age)
+	if data, err = json.Marshal(e); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(s.path(key), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	mod := time.Now().Add(-age)
+	os.Chtimes(s.path(key), mod, mod)
+}
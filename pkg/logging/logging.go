@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// Default number of bytes of a payload kept in the main log
+const DefaultPayloadLimit = 512
+
+var payloadLimit atomic.Int64
+
+func init() {
+	payloadLimit.Store(DefaultPayloadLimit)
+}
+
+// Google API keys and key query parameters that may appear in error messages
+var secretPattern = regexp.MustCompile(`AIza[0-9A-Za-z_\-]{35}|([?&]key=)[^&\s"]+`)
+
+// Fingerprint returns a short, non-reversible identifier for a secret
+func Fingerprint(secret string) string {
+	if secret == "" {
+		return "key:none"
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return "key:" + hex.EncodeToString(sum[:4])
+}
+
+// Redact masks anything that looks like an API key in s
+func Redact(s string) string {
+	return secretPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if sub := secretPattern.FindStringSubmatch(match); sub[1] != "" {
+			return sub[1] + "REDACTED"
+		}
+		return Fingerprint(match)
+	})
+}
+
+// SetPayloadLimit sets how many bytes of a payload Truncate keeps; 0 or less disables truncation
+func SetPayloadLimit(n int) {
+	payloadLimit.Store(int64(n))
+}
+
+// Truncate shortens s to the configured payload limit, backing off to a rune boundary
+func Truncate(s string) string {
+	limit := int(payloadLimit.Load())
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return fmt.Sprintf("%s… (%d more bytes)", s[:limit], len(s)-limit)
+}
+
+// Printf logs through the standard logger with secrets redacted
+func Printf(format string, args ...any) {
+	log.Print(Redact(fmt.Sprintf(format, args...)))
+}
+
+var prompts struct {
+	sync.Mutex
+	file *os.File
+}
+
+// SetPromptLog opens path for full, untruncated prompt logging. An empty
+// path turns prompt logging off.
+func SetPromptLog(path string) error {
+	prompts.Lock()
+	defer prompts.Unlock()
+
+	if prompts.file != nil {
+		prompts.file.Close()
+		prompts.file = nil
+	}
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	prompts.file = f
+	return nil
+}
+
+// Prompt records a full payload to the prompt log when it is enabled
+func Prompt(label, payload string) {
+	prompts.Lock()
+	defer prompts.Unlock()
+
+	if prompts.file == nil {
+		return
+	}
+	fmt.Fprintf(prompts.file, "=== %s %s\n%s\n\n", time.Now().Format(time.RFC3339), label, Redact(payload))
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		in    string
+		want  string
+	}{
+		{"short", 8, "news", "news"},
+		{"exact", 4, "news", "news"},
+		{"ascii", 4, "headline", "head… (4 more bytes)"},
+		{"disabled", 0, "headline", "headline"},
+		{"mid rune", 2, "née", "n… (3 more bytes)"},
+		{"rune boundary", 3, "née", "né… (1 more bytes)"},
+		{"first rune split", 2, "€uro", "… (6 more bytes)"},
+	}
+	defer SetPayloadLimit(DefaultPayloadLimit)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPayloadLimit(tt.limit)
+			got := Truncate(tt.in)
+			if got != tt.want {
+				t.Errorf("Truncate(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("Truncate(%q) = %q is not valid UTF-8", tt.in, got)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	key := "AIza" + strings.Repeat("x", 35)
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"no secret", "quota exceeded", "quota exceeded"},
+		{"bare key", "bad key " + key, "bad key " + Fingerprint(key)},
+		{"query param", "GET /v1/models?key=secret&alt=json", "GET /v1/models?key=REDACTED&alt=json"},
+		{"second param", "GET /v1?alt=json&key=secret", "GET /v1?alt=json&key=REDACTED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.in); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package pool
+
+import (
+	"log"
+	"time"
+
+	"github.com/renniemaharaj/news/pkg/logging"
+	"github.com/renniemaharaj/news/pkg/transformer"
+	"github.com/renniemaharaj/news/pkg/transformer/gemi"
+)
+
+// Base cooldowns, vars so tests can shorten them
+var (
+	rateLimitCooldown  = 30 * time.Second
+	overloadedCooldown = 10 * time.Second
+	maxCooldown        = 10 * time.Minute
+)
+
+// keyState tracks the health and daily usage of a single API key
+type keyState struct {
+	api transformer.API
+
+	inUse     bool
+	disabled  bool
+	lastErr   error
+	failures  int                  // consecutive
+	cooldowns map[string]time.Time // per model base, quotas are tracked per model
+
+	day           string // counters below reset when the day changes
+	requestsToday int
+	tokensToday   int
+}
+
+// Resets daily counters once the date changes
+func (k *keyState) rollover(now time.Time) {
+	day := now.Format("2006-01-02")
+	if k.day != day {
+		k.day = day
+		k.requestsToday = 0
+		k.tokensToday = 0
+	}
+}
+
+// Resolves the model base a session on this key will use
+func (k *keyState) base(override string) string {
+	if override != "" {
+		return override
+	}
+	return k.api.Base
+}
+
+func (k *keyState) cooling(base string, now time.Time) bool {
+	return now.Before(k.cooldowns[base])
+}
+
+func (k *keyState) available(base string, now time.Time) bool {
+	return !k.inUse && !k.disabled && !k.cooling(k.base(base), now)
+}
+
+// Reports whether k is a healthier pick than other
+func (k *keyState) healthier(other *keyState) bool {
+	if k.failures != other.failures {
+		return k.failures < other.failures
+	}
+	if k.requestsToday != other.requestsToday {
+		return k.requestsToday < other.requestsToday
+	}
+	return k.tokensToday < other.tokensToday
+}
+
+// Doubles the cooldown for each consecutive failure, up to maxCooldown
+func backoffCooldown(base time.Duration, failures int) time.Duration {
+	d := base
+	for i := 1; i < failures && d < maxCooldown; i++ {
+		d *= 2
+	}
+	if d > maxCooldown {
+		d = maxCooldown
+	}
+	return d
+}
+
+// Returns a key to the pool and records the outcome of its session on base
+func (p *Instance) release(key *keyState, base string, requests, tokens int, outcome error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.dispatchLocked() // hand the key straight to the next waiter
+
+	now := time.Now()
+	key.rollover(now)
+	key.inUse = false
+	key.requestsToday += requests
+	key.tokensToday += tokens
+	key.lastErr = outcome
+
+	// A reply that failed validation still proves the key works
+	kind := gemi.Classify(outcome)
+	if kind == gemi.ErrNone || kind == gemi.ErrInvalidResponse {
+		key.failures = 0
+		return
+	}
+
+	key.failures++
+	switch kind {
+	case gemi.ErrInvalidKey:
+		key.disabled = true
+		logging.Printf("⛔ Disabling %s permanently: %v", logging.Fingerprint(key.api.Key), outcome)
+	case gemi.ErrRateLimited:
+		p.coolDownLocked(key, base, now.Add(backoffCooldown(rateLimitCooldown, key.failures)))
+		log.Printf("🧊 %s rate limited on %s, cooling down until %s", logging.Fingerprint(key.api.Key), base, key.cooldowns[base].Format(time.TimeOnly))
+	case gemi.ErrOverloaded:
+		p.coolDownLocked(key, base, now.Add(backoffCooldown(overloadedCooldown, key.failures)))
+	}
+}
+
+// Cools key down on base and schedules a dispatch for when it ends. Waiters
+// only arm timers for idle keys, so without this a waiter blocked on a key
+// that was in use when they queued would never see it come back. Must hold p.mu.
+func (p *Instance) coolDownLocked(key *keyState, base string, until time.Time) {
+	key.coolDown(base, until)
+	time.AfterFunc(time.Until(until), func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.dispatchLocked()
+	})
+}
+
+func (k *keyState) coolDown(base string, until time.Time) {
+	if k.cooldowns == nil {
+		k.cooldowns = map[string]time.Time{}
+	}
+	k.cooldowns[base] = until
+}
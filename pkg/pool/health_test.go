@@ -0,0 +1,140 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/renniemaharaj/news/pkg/transformer/gemi"
+)
+
+func TestBackoffCooldown(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     time.Duration
+		failures int
+		want     time.Duration
+	}{
+		{"first failure", 30 * time.Second, 1, 30 * time.Second},
+		{"second failure doubles", 30 * time.Second, 2, time.Minute},
+		{"third failure doubles again", 30 * time.Second, 3, 2 * time.Minute},
+		{"capped", 30 * time.Second, 10, maxCooldown},
+		{"base over cap", time.Hour, 1, maxCooldown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backoffCooldown(tt.base, tt.failures); got != tt.want {
+				t.Errorf("backoffCooldown(%v, %d) = %v, want %v", tt.base, tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	rateLimited := errors.New("Error 429: RESOURCE_EXHAUSTED")
+
+	tests := []struct {
+		name         string
+		outcomes     []error
+		wantDisabled bool
+		wantFailures int
+		wantCooldown time.Duration // expected length of the last cooldown, 0 for none
+	}{
+		{"success", []error{nil}, false, 0, 0},
+		{"invalid key disables", []error{errors.New("API_KEY_INVALID")}, true, 1, 0},
+		{"rate limited cools down", []error{rateLimited}, false, 1, rateLimitCooldown},
+		{"repeated rate limits double", []error{rateLimited, rateLimited}, false, 2, 2 * rateLimitCooldown},
+		{"success resets failures", []error{rateLimited, nil}, false, 0, rateLimitCooldown},
+		{"invalid response keeps key healthy", []error{rateLimited, &gemi.InvalidResponseError{Err: errors.New("bad json")}}, false, 0, rateLimitCooldown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPool("a")
+			key := p.keys[0]
+
+			var start time.Time
+			for _, outcome := range tt.outcomes {
+				key.inUse = true
+				before, now := key.cooldowns["flash"], time.Now()
+				p.release(key, "flash", 1, 0, outcome)
+				if !key.cooldowns["flash"].Equal(before) {
+					start = now
+				}
+			}
+
+			if key.disabled != tt.wantDisabled {
+				t.Errorf("disabled = %v, want %v", key.disabled, tt.wantDisabled)
+			}
+			if key.failures != tt.wantFailures {
+				t.Errorf("failures = %d, want %d", key.failures, tt.wantFailures)
+			}
+			if key.inUse {
+				t.Errorf("key still in use after release")
+			}
+
+			until, cooling := key.cooldowns["flash"]
+			if tt.wantCooldown == 0 {
+				if cooling {
+					t.Errorf("unexpected cooldown until %v", until)
+				}
+				return
+			}
+			// Allow for the time between recording start and the release
+			if got := until.Sub(start); got < tt.wantCooldown || got > tt.wantCooldown+time.Second {
+				t.Errorf("cooldown = %v, want %v", got, tt.wantCooldown)
+			}
+		})
+	}
+}
+
+func TestPickHealthiest(t *testing.T) {
+	type health struct {
+		failures, requests, tokens int
+		inUse, disabled, cooling   bool
+	}
+
+	tests := []struct {
+		name string
+		keys []health
+		want int // index of the expected pick, -1 for none
+	}{
+		{"fewest failures", []health{{failures: 2}, {failures: 0, requests: 50}, {failures: 1}}, 1},
+		{"fewest requests", []health{{requests: 9}, {requests: 3}, {requests: 5}}, 1},
+		{"fewest tokens", []health{{requests: 1, tokens: 900}, {requests: 1, tokens: 100}}, 1},
+		{"skips in use", []health{{inUse: true}, {requests: 5}}, 1},
+		{"skips disabled", []health{{disabled: true}, {failures: 3}}, 1},
+		{"skips cooling", []health{{cooling: true}, {failures: 3}}, 1},
+		{"none available", []health{{inUse: true}, {disabled: true}, {cooling: true}}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			names := make([]string, len(tt.keys))
+			for i := range names {
+				names[i] = string(rune('a' + i))
+			}
+			p := newPool(names...)
+
+			for i, h := range tt.keys {
+				key := p.keys[i]
+				key.rollover(now)
+				key.failures, key.requestsToday, key.tokensToday = h.failures, h.requests, h.tokens
+				key.inUse, key.disabled = h.inUse, h.disabled
+				if h.cooling {
+					key.coolDown("flash", now.Add(time.Hour))
+				}
+			}
+
+			got := p.pickLocked("", now)
+			if tt.want < 0 {
+				if got != nil {
+					t.Fatalf("picked %s, want none", got.api.Key)
+				}
+				return
+			}
+			if got != p.keys[tt.want] {
+				t.Fatalf("picked %v, want %s", got, p.keys[tt.want].api.Key)
+			}
+		})
+	}
+}
@@ -9,23 +9,41 @@ import (
 	"sync"
 	"time"
 
-	"github.com/google/generative-ai-go/genai"
+	"github.com/renniemaharaj/news/pkg/logging"
 	"github.com/renniemaharaj/news/pkg/transformer"
 	"github.com/renniemaharaj/news/pkg/transformer/gemi"
 )
 
+// Default environment variable holding the JSON array of API keys
+const keysEnvVar = "GEMINI_API_KEYS_POOL"
+
 type Instance struct {
-	Channel chan *transformer.API
+	EnvVar string // environment variable holding the keys, defaults to GEMINI_API_KEYS_POOL
+
+	mu      sync.Mutex
+	keys    []*keyState
+	waiters []*waiter // FIFO queue of callers waiting for a key
 	once    sync.Once // ensures initialization happens only once
+}
 
+// Result is a validated model response and the session that produced it
+type Result struct {
+	Response   string
+	Base       string
+	Key        string // fingerprint of the API key used
+	Parameters transformer.Parameters
+	Applied    string // built-in set and overrides that produced Parameters
 }
 
 // Initialize API key pool
 func (p *Instance) HydrateChannels(keys []transformer.API) {
 	p.once.Do(func() {
-		p.Channel = make(chan *transformer.API, len(keys))
-		for _, key := range keys {
-			p.Channel <- &key
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.keys = make([]*keyState, len(keys))
+		for i := range keys {
+			p.keys[i] = &keyState{api: keys[i]}
 		}
 		log.Printf("API Key Pool Initialized with %d keys", len(keys))
 	})
@@ -33,6 +51,21 @@ func (p *Instance) HydrateChannels(keys []transformer.API) {
 
 // LoadGeminiAPIPool loads API keys from an environment variable.
 func (p *Instance) LoadEnv_GEMINI_API_KEYS_POOL(envVar string) ([]transformer.API, error) {
+	keys, err := loadKeys(envVar)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Loaded %d API keys from environment variable %s", len(keys), envVar)
+
+	// Initialize the API key pool
+	p.HydrateChannels(keys)
+
+	return keys, nil
+}
+
+// Parses the JSON array of API keys held in an environment variable
+func loadKeys(envVar string) ([]transformer.API, error) {
 	jsonStr := os.Getenv(envVar)
 	if jsonStr == "" {
 		return nil, fmt.Errorf("environment variable %s is empty", envVar)
@@ -47,17 +80,36 @@ func (p *Instance) LoadEnv_GEMINI_API_KEYS_POOL(envVar string) ([]transformer.AP
 		return nil, fmt.Errorf("no API keys found in environment variable %s", envVar)
 	}
 
-	log.Printf("Loaded %d API keys from environment variable %s", len(keys), envVar)
+	return keys, nil
+}
 
-	// Initialize the API key pool
-	p.HydrateChannels(keys)
+// Bases returns the distinct model bases configured across the pool's keys
+func (p *Instance) Bases() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	return keys, nil
+	seen := map[string]struct{}{}
+	var bases []string
+	for _, key := range p.keys {
+		if _, ok := seen[key.api.Base]; ok {
+			continue
+		}
+		seen[key.api.Base] = struct{}{}
+		bases = append(bases, key.api.Base)
+	}
+	return bases
+}
+
+func (p *Instance) envVar() string {
+	if p.EnvVar != "" {
+		return p.EnvVar
+	}
+	return keysEnvVar
 }
 
 // InitializePool initializes the API key pool.
 func (p *Instance) InitializePool() {
-	keys, err := p.LoadEnv_GEMINI_API_KEYS_POOL("GEMINI_API_KEYS_POOL")
+	keys, err := p.LoadEnv_GEMINI_API_KEYS_POOL(p.envVar())
 	if err != nil {
 		log.Println(err)
 		return
@@ -65,81 +117,171 @@ func (p *Instance) InitializePool() {
 	p.HydrateChannels(keys)
 }
 
+// Reload re-reads the API keys from the environment and swaps them in,
+// keeping the health state of keys that are still configured
+func (p *Instance) Reload() error {
+	keys, err := loadKeys(p.envVar())
+	if err != nil {
+		return err
+	}
+
+	p.once.Do(func() {}) // a reload supersedes any pending initialization
+	p.Replace(keys)
+	return nil
+}
+
+// Replace swaps in a new set of keys. Keys in use are returned to the pool
+// only if they are still configured once released.
+func (p *Instance) Replace(keys []transformer.API) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.dispatchLocked()
+
+	existing := map[transformer.API]*keyState{}
+	for _, key := range p.keys {
+		existing[key.api] = key
+	}
+
+	next := make([]*keyState, len(keys))
+	for i := range keys {
+		if key, ok := existing[keys[i]]; ok {
+			next[i] = key
+			continue
+		}
+		next[i] = &keyState{api: keys[i]}
+	}
+
+	p.keys = next
+	log.Printf("🔄 API Key Pool reloaded with %d keys", len(keys))
+}
+
 // QueuedEVS queues, exponential backoff, validating model responses.
-func (p *Instance) QueuedEVS(ctx context.Context, input gemi.Input, validate func(resp string) error, queueTries int, backoff int) (string, error) {
+//
+// models is an ordered fallback chain of model bases. A model that is
+// overloaded or out of quota, or whose replies keep failing validation for
+// queueTries sessions, escalates to the next one. With no chain every key
+// uses its own base.
+func (p *Instance) QueuedEVS(ctx context.Context, models []string, input gemi.Input, validate func(resp string) error, queueTries int, backoff int) (Result, error) {
 	timeStart := time.Now()
 	log.Println("Starting queued-based EVS with exponential backoff and validation...")
-	for i := 0; i < queueTries; i++ {
-		log.Printf("Attempt %d of %d", i+1, queueTries)
-		session, cleanup, err := p.Queue(ctx)
 
-		if err != nil {
-			log.Printf("Failed to get session: %v", err)
-			continue
-		}
+	chain := models
+	if len(chain) == 0 {
+		chain = []string{""}
+	}
 
-		resp, err := session.ExponentiallyValidateSend(ctx, &input, validate, backoff)
-		cleanup()
+	for m, model := range chain {
+		if model != "" {
+			log.Printf("🧠 Trying model %s (%d of %d)", model, m+1, len(chain))
+		}
 
-		if err != nil {
-			log.Printf("Failed to validate response: %v", err)
+		// Waiting out a cooldown is pointless when another model can serve us
+		if m < len(chain)-1 && p.allCooling(model) {
+			log.Printf("⏭️ All keys cooling down on %s, escalating", model)
 			continue
 		}
 
-		log.Printf("Success after %d attempts, took %v", i+1, time.Since(timeStart))
-		return resp, nil
+		for i := 0; i < queueTries; i++ {
+			log.Printf("Attempt %d of %d", i+1, queueTries)
+			session, release, err := p.Queue(ctx, model, &input)
+
+			if err != nil {
+				logging.Printf("Failed to get session: %v", err)
+				continue
+			}
+
+			resp, err := session.ExponentiallyValidateSend(ctx, &input, validate, backoff)
+			base := session.Base
+			release(err)
+
+			if err == nil {
+				log.Printf("Success after %d attempts, took %v", i+1, time.Since(timeStart))
+				return Result{
+					Response:   resp,
+					Base:       base,
+					Key:        session.Key,
+					Parameters: session.Parameters,
+					Applied:    session.Applied,
+				}, nil
+			}
+
+			logging.Printf("Failed to validate response: %v", logging.Truncate(err.Error()))
+			if kind := gemi.Classify(err); kind == gemi.ErrOverloaded || kind == gemi.ErrRateLimited {
+				log.Printf("⏭️ Model %s is %s, escalating", base, kind)
+				break
+			}
+		}
 	}
 
 	log.Printf("failed to validate pool response over %dqueues * %v = %v (since)", queueTries, backoff, time.Since(timeStart))
-	return "", fmt.Errorf("failed to validate pool response over %dqueues * %v = %v (since)", queueTries, backoff, time.Since(timeStart))
+	return Result{}, fmt.Errorf("failed to validate pool response over %dqueues * %v = %v (since)", queueTries, backoff, time.Since(timeStart))
 }
 
-// Queue returns a session from the pool of available API keys.
-func (p *Instance) Queue(ctx context.Context) (*gemi.Session, func(), error) {
+// Queue returns a session from the pool of available API keys, waiting in
+// FIFO order until one frees up or ctx expires. A non-empty base overrides
+// the key's own model, and the input's tuning and stage select the model
+// parameters. The returned release func must be called with the outcome of
+// the session so the key's health is updated before it is handed out again.
+func (p *Instance) Queue(ctx context.Context, base string, input *gemi.Input) (*gemi.Session, func(error), error) {
 	log.Println("Waiting for available key...")
 
-	// Non-blocking key retrieval
-	select {
-	case api := <-p.Channel:
-		log.Printf("Using key: %s", api.Key)
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultAcquireTimeout)
+		defer cancel()
+	}
 
-		// New configuration
-		cfx := transformer.Configuration{
-			Key:        *api,
-			Parameters: api.Parameters(),
-		}
+	key, err := p.acquire(ctx, base)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		// Set system instruction
-		cfx.Parameters.SystemInstruction.Parts = []genai.Part{
-			genai.Text(genai.Text(transformer.GetProgramming())),
-		}
+	api := key.api
+	api.Base = key.base(base)
+	log.Printf("Using key: %s", logging.Fingerprint(api.Key))
 
-		log.Println("Creating model...")
-		model, cleanup, err := gemi.Model(ctx, cfx)
-		if err != nil {
-			p.Channel <- api                              // return key if model creation fails
-			log.Printf("Freeing key (Fail): %s", api.Key) // Log key release
-			return nil, nil, fmt.Errorf("error creating model: %w", err)
-		}
+	params, applied := input.Tuning.Resolve(api.Base, input.Stage)
+	log.Printf("⚙️ Using parameters %s", applied)
 
-		// Custom cleanup to return the key back when done
-		cleanupFunc := func() {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("Recovered from panic in cleanup: %v", r)
-				}
-				log.Printf("Freeing key (Finish): %s", api.Key) // Log key release
-				p.Channel <- api                                // Always return key
-			}()
-
-			cleanup() // Call original cleanup
-		}
+	// New configuration
+	cfx := transformer.Configuration{
+		Key:        api,
+		Parameters: params,
+	}
+
+	// Set system instruction, a fresh copy per session
+	if input.Instruction != "" {
+		cfx.Parameters.SystemInstruction = transformer.Instruction(input.Instruction)
+	}
+
+	log.Println("Creating model...")
+	model, cleanup, err := gemi.Model(ctx, cfx)
+	if err != nil {
+		p.release(key, api.Base, 0, 0, err)                                // return key if model creation fails
+		log.Printf("Freeing key (Fail): %s", logging.Fingerprint(api.Key)) // Log key release
+		return nil, nil, fmt.Errorf("error creating model: %w", err)
+	}
 
-		session := gemi.Session{Model: model}
+	session := gemi.Session{
+		Model:      model,
+		Base:       api.Base,
+		Key:        logging.Fingerprint(api.Key),
+		Parameters: params,
+		Applied:    applied,
+	}
 
-		return &session, cleanupFunc, nil
+	// Custom release to return the key back when done
+	release := func(outcome error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Recovered from panic in cleanup: %v", r)
+			}
+			log.Printf("Freeing key (Finish): %s", logging.Fingerprint(api.Key)) // Log key release
+			p.release(key, api.Base, session.Requests, session.Tokens, outcome)  // Always return key
+		}()
 
-	default:
-		return nil, nil, fmt.Errorf("no API keys available")
+		cleanup() // Call original cleanup
 	}
+
+	return &session, release, nil
 }
@@ -0,0 +1,93 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+
+	"github.com/renniemaharaj/news/pkg/transformer"
+	"github.com/renniemaharaj/news/pkg/transformer/gemi"
+)
+
+func TestQueueConcurrent(t *testing.T) {
+	const keys, callers = 3, 24
+
+	report, repair := float32(0.2), float32(0.9)
+	tuning := &transformer.Tuning{Stages: map[string]transformer.Overrides{
+		transformer.StageReport: {Temperature: &report},
+		transformer.StageRepair: {Temperature: &repair},
+	}}
+
+	names := make([]string, keys)
+	for i := range names {
+		names[i] = fmt.Sprintf("key-%d", i)
+	}
+	p := newPool(names...)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var (
+		mu           sync.Mutex
+		instructions = map[*genai.Content]int{} // held instruction to its caller
+		held, peak   int
+		wg           sync.WaitGroup
+	)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			stage, temperature := transformer.StageReport, report
+			if i%2 == 1 {
+				stage, temperature = transformer.StageRepair, repair
+			}
+			text := fmt.Sprintf("instruction %d", i)
+			input := &gemi.Input{Instruction: text, Stage: stage, Tuning: tuning}
+
+			session, release, err := p.Queue(ctx, "", input)
+			if err != nil {
+				t.Errorf("caller %d: %v", i, err)
+				return
+			}
+			defer release(nil)
+
+			si := session.Model.SystemInstruction
+			mu.Lock()
+			held++
+			peak = max(peak, held)
+			if other, ok := instructions[si]; ok {
+				t.Errorf("callers %d and %d share a system instruction", i, other)
+			}
+			instructions[si] = i
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond) // hold the key so later callers queue
+
+			if got := si.Parts[0].(genai.Text); string(got) != text {
+				t.Errorf("caller %d got instruction %q", i, got)
+			}
+			if got := session.Model.Temperature; got == nil || *got != temperature {
+				t.Errorf("caller %d got temperature %v, want %v", i, got, temperature)
+			}
+
+			mu.Lock()
+			held--
+			delete(instructions, si)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if peak > keys {
+		t.Errorf("%d sessions held at once with %d keys", peak, keys)
+	}
+	for _, key := range p.keys {
+		if key.inUse {
+			t.Errorf("%s still in use after every session was released", key.api.Key)
+		}
+	}
+}
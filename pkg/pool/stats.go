@@ -0,0 +1,43 @@
+package pool
+
+import (
+	"fmt"
+	"time"
+)
+
+// Stats is a point-in-time view of the pool's keys
+type Stats struct {
+	Total       int `json:"total"`
+	InUse       int `json:"in_use"`
+	Idle        int `json:"idle"`
+	CoolingDown int `json:"cooling_down"`
+	Disabled    int `json:"disabled"`
+	Waiting     int `json:"waiting"`
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("keys: total=%d in-use=%d idle=%d cooling-down=%d disabled=%d waiting=%d",
+		s.Total, s.InUse, s.Idle, s.CoolingDown, s.Disabled, s.Waiting)
+}
+
+// Stats counts keys by state
+func (p *Instance) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	stats := Stats{Total: len(p.keys), Waiting: len(p.waiters)}
+	for _, key := range p.keys {
+		switch {
+		case key.disabled:
+			stats.Disabled++
+		case key.inUse:
+			stats.InUse++
+		case key.cooling(key.api.Base, now):
+			stats.CoolingDown++
+		default:
+			stats.Idle++
+		}
+	}
+	return stats
+}
@@ -0,0 +1,181 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Bounds how long Queue waits when the caller's context has no deadline
+const defaultAcquireTimeout = 2 * time.Minute
+
+var errNoUsableKeys = errors.New("no usable API keys in pool")
+
+// waiter is a caller queued for a key that can serve base
+type waiter struct {
+	ch   chan *keyState
+	base string
+}
+
+// Reserves the healthiest key available for base, waiting behind earlier
+// callers until one is released, a cooldown expires, or ctx is done
+func (p *Instance) acquire(ctx context.Context, base string) (*keyState, error) {
+	p.mu.Lock()
+	if !p.anyEnabledLocked() {
+		p.mu.Unlock()
+		return nil, errNoUsableKeys
+	}
+
+	// Only skip the queue when nobody is already waiting
+	if len(p.waiters) == 0 {
+		if key := p.pickLocked(base, time.Now()); key != nil {
+			key.inUse = true
+			p.mu.Unlock()
+			return key, nil
+		}
+	}
+
+	// Queue behind earlier callers, but take a key none of them can use
+	ch := make(chan *keyState, 1)
+	p.waiters = append(p.waiters, &waiter{ch: ch, base: base})
+	p.dispatchLocked()
+	p.mu.Unlock()
+
+	for {
+		timer, wake := p.cooldownTimer()
+
+		select {
+		case key := <-ch:
+			stop(timer)
+			if key == nil {
+				return nil, errNoUsableKeys
+			}
+			return key, nil
+
+		case <-wake:
+			p.mu.Lock()
+			p.dispatchLocked()
+			p.mu.Unlock()
+
+		case <-ctx.Done():
+			stop(timer)
+			p.abandon(ch)
+			return nil, fmt.Errorf("no API keys available: %w", ctx.Err())
+		}
+	}
+}
+
+// Removes a cancelled waiter, returning any key handed to it in the meantime
+func (p *Instance) abandon(ch chan *keyState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, w := range p.waiters {
+		if w.ch == ch {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			break
+		}
+	}
+
+	select {
+	case key := <-ch:
+		if key != nil {
+			key.inUse = false
+			p.dispatchLocked()
+		}
+	default:
+	}
+}
+
+// Hands available keys to waiters in arrival order. A waiter whose model is
+// cooling down on every key does not hold up waiters for other models.
+// Must hold p.mu.
+func (p *Instance) dispatchLocked() {
+	if !p.anyEnabledLocked() {
+		for _, w := range p.waiters {
+			w.ch <- nil
+		}
+		p.waiters = nil
+		return
+	}
+
+	now := time.Now()
+	remaining := p.waiters[:0]
+	for _, w := range p.waiters {
+		key := p.pickLocked(w.base, now)
+		if key == nil {
+			remaining = append(remaining, w)
+			continue
+		}
+		key.inUse = true
+		w.ch <- key
+	}
+	p.waiters = remaining
+}
+
+// Picks the healthiest key available for base without reserving it. Must hold p.mu.
+func (p *Instance) pickLocked(base string, now time.Time) *keyState {
+	var best *keyState
+	for _, key := range p.keys {
+		key.rollover(now)
+		if key.available(base, now) && (best == nil || key.healthier(best)) {
+			best = key
+		}
+	}
+	return best
+}
+
+// Reports whether every enabled key is cooling down on base
+func (p *Instance) allCooling(base string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for _, key := range p.keys {
+		if !key.disabled && !key.cooling(key.base(base), now) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Instance) anyEnabledLocked() bool {
+	for _, key := range p.keys {
+		if !key.disabled {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns a timer firing when the earliest idle key leaves cooldown, if any
+func (p *Instance) cooldownTimer() (*time.Timer, <-chan time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var next time.Time
+	for _, key := range p.keys {
+		if key.disabled || key.inUse {
+			continue
+		}
+		for _, until := range key.cooldowns {
+			if until.After(now) && (next.IsZero() || until.Before(next)) {
+				next = until
+			}
+		}
+	}
+
+	if next.IsZero() {
+		return nil, nil
+	}
+	timer := time.NewTimer(next.Sub(now))
+	return timer, timer.C
+}
+
+func stop(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
@@ -0,0 +1,183 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/renniemaharaj/news/pkg/transformer"
+)
+
+func newPool(keys ...string) *Instance {
+	p := &Instance{}
+	api := make([]transformer.API, len(keys))
+	for i, key := range keys {
+		api[i] = transformer.API{Key: key, Base: "flash"}
+	}
+	p.HydrateChannels(api)
+	return p
+}
+
+func shortCooldowns(t *testing.T) {
+	rate, overloaded := rateLimitCooldown, overloadedCooldown
+	rateLimitCooldown, overloadedCooldown = 50*time.Millisecond, 50*time.Millisecond
+	t.Cleanup(func() { rateLimitCooldown, overloadedCooldown = rate, overloaded })
+}
+
+// Acquires in a goroutine, delivering the result on the returned channel
+func acquireAsync(p *Instance, ctx context.Context) <-chan *keyState {
+	return acquireModel(p, ctx, "")
+}
+
+func acquireModel(p *Instance, ctx context.Context, base string) <-chan *keyState {
+	got := make(chan *keyState, 1)
+	go func() {
+		key, _ := p.acquire(ctx, base)
+		got <- key
+	}()
+	return got
+}
+
+// Waits until n callers are queued so arrival order is deterministic
+func waitQueued(t *testing.T, p *Instance, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		p.mu.Lock()
+		queued := len(p.waiters)
+		p.mu.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("waiters never reached %d", n)
+}
+
+func TestReleaseWakesWaiter(t *testing.T) {
+	shortCooldowns(t)
+
+	tests := []struct {
+		name    string
+		outcome error
+	}{
+		{"success", nil},
+		{"rate limited", errors.New("Error 429: RESOURCE_EXHAUSTED")},
+		{"overloaded", errors.New("Error 503: model overloaded")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPool("a")
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			key, err := p.acquire(ctx, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Queued while the only key is in use, so no cooldown timer is armed
+			got := acquireAsync(p, ctx)
+			waitQueued(t, p, 1)
+
+			p.release(key, "flash", 1, 0, tt.outcome)
+			if woken := <-got; woken != key {
+				t.Fatalf("waiter got %v, want the released key", woken)
+			}
+		})
+	}
+}
+
+func TestDispatchFIFO(t *testing.T) {
+	tests := []struct {
+		name    string
+		waiters int
+	}{
+		{"one", 1},
+		{"three", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPool("a")
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			key, err := p.acquire(ctx, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			queue := make([]<-chan *keyState, tt.waiters)
+			for i := range queue {
+				queue[i] = acquireAsync(p, ctx)
+				waitQueued(t, p, i+1)
+			}
+
+			for i, got := range queue {
+				p.release(key, "flash", 1, 0, nil)
+				if key = <-got; key == nil {
+					t.Fatalf("waiter %d got no key", i)
+				}
+				p.mu.Lock()
+				queued := len(p.waiters)
+				p.mu.Unlock()
+				if want := tt.waiters - i - 1; queued != want {
+					t.Fatalf("after waiter %d: %d still queued, want %d", i, queued, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAcquireNoUsableKeys(t *testing.T) {
+	p := newPool("a")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	key, err := p.acquire(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := acquireAsync(p, ctx)
+	waitQueued(t, p, 1)
+
+	p.release(key, "flash", 1, 0, errors.New("API_KEY_INVALID"))
+	if woken := <-got; woken != nil {
+		t.Fatalf("waiter got a disabled key")
+	}
+	if _, err := p.acquire(ctx, ""); !errors.Is(err, errNoUsableKeys) {
+		t.Fatalf("acquire = %v, want %v", err, errNoUsableKeys)
+	}
+}
+
+func TestAcquireOtherModelWhileQueued(t *testing.T) {
+	p := newPool("a", "b")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	busy, err := p.acquire(ctx, "m1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.mu.Lock()
+	var idle *keyState
+	for _, key := range p.keys {
+		if key != busy {
+			idle = key
+		}
+	}
+	idle.coolDown("m1", time.Now().Add(time.Hour))
+	p.mu.Unlock()
+
+	// Blocked: one key is in use and the other is cooling on m1
+	acquireModel(p, ctx, "m1")
+	waitQueued(t, p, 1)
+
+	key, err := p.acquire(ctx, "m2")
+	if err != nil {
+		t.Fatalf("acquire for m2 behind an m1 waiter: %v", err)
+	}
+	if key != idle {
+		t.Errorf("acquire for m2 got the busy key")
+	}
+}
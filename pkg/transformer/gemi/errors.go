@@ -0,0 +1,94 @@
+package gemi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorKind classifies model API errors by how a key pool should react to them
+type ErrorKind int
+
+const (
+	ErrNone            ErrorKind = iota
+	ErrRateLimited               // quota or rate limit hit, retry later
+	ErrInvalidKey                // key rejected, never retry
+	ErrOverloaded                // model temporarily unavailable
+	ErrInvalidResponse           // the key works but the reply failed validation
+	ErrOther
+)
+
+// InvalidResponseError wraps a validation failure of a model reply
+type InvalidResponseError struct {
+	Err error
+}
+
+func (e *InvalidResponseError) Error() string { return e.Err.Error() }
+func (e *InvalidResponseError) Unwrap() error { return e.Err }
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrNone:
+		return "none"
+	case ErrRateLimited:
+		return "rate-limited"
+	case ErrInvalidKey:
+		return "invalid-key"
+	case ErrOverloaded:
+		return "overloaded"
+	case ErrInvalidResponse:
+		return "invalid-response"
+	}
+	return "other"
+}
+
+// Classify inspects an error returned by the model API
+func Classify(err error) ErrorKind {
+	if err == nil {
+		return ErrNone
+	}
+
+	var invalid *InvalidResponseError
+	if errors.As(err, &invalid) {
+		return ErrInvalidResponse
+	}
+
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		switch gerr.Code {
+		case http.StatusTooManyRequests:
+			return ErrRateLimited
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return ErrInvalidKey
+		case http.StatusServiceUnavailable:
+			return ErrOverloaded
+		}
+	}
+
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.ResourceExhausted:
+			return ErrRateLimited
+		case codes.Unauthenticated, codes.PermissionDenied:
+			return ErrInvalidKey
+		case codes.Unavailable:
+			return ErrOverloaded
+		}
+	}
+
+	// Fall back to the messages the API embeds in wrapped errors
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "api_key_invalid"), strings.Contains(msg, "api key not valid"), strings.Contains(msg, "api key expired"):
+		return ErrInvalidKey
+	case strings.Contains(msg, "resource_exhausted"), strings.Contains(msg, "quota"), strings.Contains(msg, "error 429"):
+		return ErrRateLimited
+	case strings.Contains(msg, "overloaded"), strings.Contains(msg, "unavailable"), strings.Contains(msg, "error 503"):
+		return ErrOverloaded
+	}
+	return ErrOther
+}
@@ -0,0 +1,49 @@
+package gemi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{"nil", nil, ErrNone},
+		{"googleapi 429", &googleapi.Error{Code: http.StatusTooManyRequests}, ErrRateLimited},
+		{"googleapi 401", &googleapi.Error{Code: http.StatusUnauthorized}, ErrInvalidKey},
+		{"googleapi 403", &googleapi.Error{Code: http.StatusForbidden}, ErrInvalidKey},
+		{"googleapi 503", &googleapi.Error{Code: http.StatusServiceUnavailable}, ErrOverloaded},
+		{"googleapi 500", &googleapi.Error{Code: http.StatusInternalServerError}, ErrOther},
+		{"wrapped googleapi", fmt.Errorf("send: %w", &googleapi.Error{Code: http.StatusTooManyRequests}), ErrRateLimited},
+		{"grpc resource exhausted", status.Error(codes.ResourceExhausted, "slow down"), ErrRateLimited},
+		{"grpc unauthenticated", status.Error(codes.Unauthenticated, "who"), ErrInvalidKey},
+		{"grpc permission denied", status.Error(codes.PermissionDenied, "no"), ErrInvalidKey},
+		{"grpc unavailable", status.Error(codes.Unavailable, "later"), ErrOverloaded},
+		{"grpc internal", status.Error(codes.Internal, "oops"), ErrOther},
+		{"string invalid key", errors.New("googleapi: Error 400: API key not valid. Please pass a valid API key."), ErrInvalidKey},
+		{"string key expired", errors.New("API key expired. Please renew the API key."), ErrInvalidKey},
+		{"string API_KEY_INVALID", errors.New("reason: API_KEY_INVALID"), ErrInvalidKey},
+		{"string quota", errors.New("googleapi: Error 429: You exceeded your current quota"), ErrRateLimited},
+		{"string resource exhausted", errors.New("rpc error: RESOURCE_EXHAUSTED"), ErrRateLimited},
+		{"string overloaded", errors.New("googleapi: Error 503: The model is overloaded."), ErrOverloaded},
+		{"string other", errors.New("connection reset by peer"), ErrOther},
+		{"invalid response", &InvalidResponseError{Err: errors.New("quota field missing")}, ErrInvalidResponse},
+		{"wrapped invalid response", fmt.Errorf("attempt 3: %w", &InvalidResponseError{Err: errors.New("bad json")}), ErrInvalidResponse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
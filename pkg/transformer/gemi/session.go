@@ -8,18 +8,43 @@ import (
 	"time"
 
 	"github.com/google/generative-ai-go/genai"
+	"github.com/renniemaharaj/news/pkg/logging"
 	"github.com/renniemaharaj/news/pkg/transformer"
 )
 
 // Session manages the generative model interactions
 type Session struct {
 	Model *genai.GenerativeModel
+	Base  string
+
+	Key        string                 // fingerprint of the API key behind the session
+	Parameters transformer.Parameters // resolved generation parameters
+	Applied    string                 // built-in set and overrides that produced Parameters
+
+	Requests int // messages sent through this session
+	Tokens   int // total tokens reported by the API
 }
 
 type Input struct {
 	Current genai.Part          `json:"current"`
 	History []*genai.Content    `json:"history"`
 	Context []map[string]string `json:"context"`
+	Schema  *genai.Schema       `json:"-"` // response schema for models in JSON mode
+
+	Instruction string `json:"-"` // rendered system instruction, empty sends none
+
+	Stage  string              `json:"-"` // pipeline stage, selects stage parameter overrides
+	Tuning *transformer.Tuning `json:"-"` // configured parameter overrides, nil uses built-ins
+
+	Trace func(label, payload string) `json:"-"` // observes every exchange, nil disables
+}
+
+// Reports an exchange to the input's tracer, if any, in full but with
+// secrets redacted. Truncation is left to the normal log path.
+func (i *Input) trace(label, payload string) {
+	if i.Trace != nil {
+		i.Trace(label, logging.Redact(payload))
+	}
 }
 
 func (i *Input) SendError(err error) {
@@ -36,42 +61,62 @@ func (s *Session) ExponentiallyValidateSend(ctx context.Context, input *Input, v
 
 	log.Println("Starting model-based interaction with exponential backoff and validation...")
 
+	var lastErr error
 	for i := 0; i < maxTries; i++ {
 		log.Printf("Attempt %d/%d\n", i+1, maxTries)
 
 		// Send input to AI
 		resp, err := s.SendInput(ctx, input)
 		if err != nil {
-			input.SendError(err)
+			lastErr = err
+			input.trace("api error", err.Error())
+			logging.Printf("API request failed: %v", err)
+
+			// Retrying a rejected or rate-limited key only burns attempts
+			if kind := Classify(err); kind == ErrRateLimited || kind == ErrInvalidKey {
+				return "", fmt.Errorf("key unusable (%s): %w", kind, err)
+			}
 
-			log.Printf("API request failed: %v", err)
+			input.SendError(err)
 			time.Sleep(time.Second << i) // Exponential backoff
 			continue
 		}
 
+		input.trace("raw response", resp)
 		linted := transformer.LintCodeFences(&resp, "json")
+		if extracted, ok := transformer.ExtractJSON(*linted); ok {
+			linted = &extracted
+		}
 
 		// Validate response
 		err = validate(*linted)
 		if err != nil {
+			lastErr = &InvalidResponseError{Err: err}
+			input.trace("validation errors", err.Error())
 			input.SendError(err)
 
-			log.Printf("Validation failed: %v <--/--> %v", err, *linted)
+			logging.Prompt("rejected response", *linted)
+			logging.Printf("Validation failed: %v <--/--> %v", err, logging.Truncate(*linted))
 			time.Sleep(time.Second << i) // Exponential backoff
 			continue
 		}
 
 		log.Printf("Response validated in %s", time.Since(startTime))
-		return resp, nil
+		return *linted, nil
 	}
 
 	// Log final failure after max attempts
 	log.Printf("Failed to validate response after %d interactions, (%s elapsed)", maxTries, time.Since(startTime))
-	return "", fmt.Errorf("failed to validate response")
+	return "", fmt.Errorf("failed to validate response: %w", lastErr)
 }
 
 // SendInput sends a message to the AI model and returns the response
 func (s *Session) SendInput(ctx context.Context, input *Input) (string, error) {
+	// The schema travels with the input; it only applies to models in JSON mode
+	if input.Schema != nil && s.Parameters.JSONMode() {
+		s.Model.ResponseSchema = input.Schema
+	}
+
 	session := s.Model.StartChat()
 
 	session.History = input.History
@@ -89,14 +134,16 @@ func (s *Session) SendInput(ctx context.Context, input *Input) (string, error) {
 		return "", fmt.Errorf("error marshalling input: %v", err)
 	}
 
-	log.Printf("Sending message to model...%v\n", string(structInputBytes))
+	logging.Prompt("input", string(structInputBytes))
+	input.trace("model input", string(structInputBytes))
+	logging.Printf("Sending message to model...%v\n", logging.Truncate(string(structInputBytes)))
 	resp, err := session.SendMessage(ctx, genai.Text(string(structInputBytes)))
+	s.record(resp)
 	if err != nil {
-		return "", fmt.Errorf("error sending message: %v", err)
+		return "", fmt.Errorf("error sending message: %w", err)
 	}
 
-	response := transformer.PartsToString(resp.Candidates[0].Content.Parts)
-	return response, nil
+	return responseText(resp)
 }
 
 // SendString sends a string message to the AI model and returns the response
@@ -104,10 +151,25 @@ func (s *Session) SendString(ctx context.Context, message string) (string, error
 	session := s.Model.StartChat()
 
 	resp, err := session.SendMessage(ctx, genai.Text(message))
+	s.record(resp)
 	if err != nil {
-		return "", fmt.Errorf("error sending message: %v", err)
+		return "", fmt.Errorf("error sending message: %w", err)
+	}
+
+	return responseText(resp)
+}
+
+// Tracks request and token usage for the key behind this session
+func (s *Session) record(resp *genai.GenerateContentResponse) {
+	s.Requests++
+	if resp != nil && resp.UsageMetadata != nil {
+		s.Tokens += int(resp.UsageMetadata.TotalTokenCount)
 	}
+}
 
-	response := transformer.PartsToString(resp.Candidates[0].Content.Parts)
-	return response, nil
+func responseText(resp *genai.GenerateContentResponse) (string, error) {
+	if resp == nil || len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return "", fmt.Errorf("empty response from model")
+	}
+	return transformer.PartsToString(resp.Candidates[0].Content.Parts), nil
 }
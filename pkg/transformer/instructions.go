@@ -1,26 +1,150 @@
 package transformer
 
 import (
-	"io"
-	"log"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
 )
 
-// GetProgramming returns the system instructions
-func GetProgramming() string {
+// Default profile used when config names none
+const (
+	DefaultProfile = "default"
+	DefaultFile    = "instructions.txt"
+)
 
-	instrFile, err := os.Open("instructions.txt")
-	if err != nil {
-		log.Println("Failed to open instructions file. Expected instructions.txt in the active directory.")
-		os.Exit(-1)
+// Profile is a named system instruction template
+type Profile struct {
+	File   string `json:"file"`
+	Locale string `json:"locale"`
+}
+
+// InstructionConfig selects the instruction profiles available to keywords
+type InstructionConfig struct {
+	Default  string             `json:"default"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// Vars are the values available to instruction templates
+type Vars struct {
+	Date    string // today, YYYY-MM-DD
+	Keyword string
+	Locale  string
+	Profile string
+}
+
+// Instructions holds parsed instruction templates by profile name
+type Instructions struct {
+	Default   string
+	profiles  map[string]Profile
+	templates map[string]*template.Template
+	hashes    map[string]string // sha256 of each profile's template source
+}
+
+// LoadInstructions reads and parses every profile's template once, reporting
+// all missing or malformed files together
+func LoadInstructions(cfg InstructionConfig) (*Instructions, error) {
+	profiles := cfg.Profiles
+	if len(profiles) == 0 {
+		profiles = map[string]Profile{DefaultProfile: {File: DefaultFile}}
+	}
+	def := cfg.Default
+	if def == "" {
+		def = DefaultProfile
+	}
+
+	in := &Instructions{
+		Default:   def,
+		profiles:  profiles,
+		templates: map[string]*template.Template{},
+		hashes:    map[string]string{},
+	}
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	if _, ok := profiles[def]; !ok {
+		problems = append(problems, fmt.Sprintf("default profile %q is not defined", def))
 	}
+	for _, name := range names {
+		data, err := os.ReadFile(profiles[name].File)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("profile %q: %v", name, err))
+			continue
+		}
 
-	defer instrFile.Close()
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(string(data))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("profile %q: %v", name, err))
+			continue
+		}
+		in.templates[name] = tmpl
+
+		sum := sha256.Sum256(data)
+		in.hashes[name] = hex.EncodeToString(sum[:])
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+	return in, nil
+}
+
+// Has reports whether a profile is loaded
+func (in *Instructions) Has(name string) bool {
+	_, ok := in.templates[name]
+	return ok
+}
+
+// Hash returns a version hash of a profile's template source
+func (in *Instructions) Hash(name string) string {
+	if name == "" {
+		name = in.Default
+	}
+	return in.hashes[name]
+}
+
+// Files lists the template file of every loaded profile
+func (in *Instructions) Files() []string {
+	files := make([]string, 0, len(in.profiles))
+	for _, profile := range in.profiles {
+		files = append(files, profile.File)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Render produces the system instruction of a profile for keyword. An empty
+// name selects the default profile.
+func (in *Instructions) Render(name, keyword string, now time.Time) (string, error) {
+	if name == "" {
+		name = in.Default
+	}
+
+	tmpl, ok := in.templates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown instruction profile %q", name)
+	}
 
-	instrBytes, err := io.ReadAll(instrFile)
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, Vars{
+		Date:    now.Format("2006-01-02"),
+		Keyword: keyword,
+		Locale:  in.profiles[name].Locale,
+		Profile: name,
+	})
 	if err != nil {
-		log.Println("Failed to read instructions file. Expected instructions.txt in the active directory.")
-		os.Exit(-1)
+		return "", fmt.Errorf("rendering profile %q: %w", name, err)
 	}
-	return string(instrBytes)
+	return buf.String(), nil
 }
@@ -0,0 +1,120 @@
+package transformer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Writes each template into dir and returns profiles pointing at them
+func writeProfiles(t *testing.T, templates map[string]string) map[string]Profile {
+	t.Helper()
+	dir := t.TempDir()
+	profiles := map[string]Profile{}
+	for name, src := range templates {
+		file := filepath.Join(dir, name+".txt")
+		if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		profiles[name] = Profile{File: file, Locale: "en-JM"}
+	}
+	return profiles
+}
+
+func TestLoadInstructions(t *testing.T) {
+	tests := []struct {
+		name      string
+		templates map[string]string
+		missing   []string // profiles whose file does not exist
+		def       string
+		wantErr   []string
+	}{
+		{"valid", map[string]string{"default": "news on {{.Keyword}}"}, nil, "", nil},
+		{"named default", map[string]string{"local": "local {{.Keyword}}"}, nil, "local", nil},
+		{"undefined default", map[string]string{"local": "local"}, nil, "", []string{`default profile "default" is not defined`}},
+		{"parse error", map[string]string{"default": "news on {{.Keyword"}, nil, "", []string{`profile "default"`}},
+		{"missing file", map[string]string{"default": "ok"}, []string{"gone"}, "", []string{`profile "gone"`}},
+		{
+			"every problem reported",
+			map[string]string{"default": "{{if}}", "local": "{{end}}"},
+			[]string{"gone"},
+			"",
+			[]string{`profile "default"`, `profile "gone"`, `profile "local"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profiles := writeProfiles(t, tt.templates)
+			for _, name := range tt.missing {
+				profiles[name] = Profile{File: filepath.Join(t.TempDir(), name+".txt")}
+			}
+
+			in, err := LoadInstructions(InstructionConfig{Default: tt.def, Profiles: profiles})
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				for name := range tt.templates {
+					if !in.Has(name) || in.Hash(name) == "" {
+						t.Errorf("profile %q not loaded", name)
+					}
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("LoadInstructions succeeded, want %q", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	in, err := LoadInstructions(InstructionConfig{
+		Default: "local",
+		Profiles: writeProfiles(t, map[string]string{
+			"local":   "{{.Profile}}: {{.Keyword}} in {{.Locale}} on {{.Date}}",
+			"faith":   "faith: {{.Keyword}}",
+			"missing": "{{.Keyword}} from {{.Region}}",
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		profile string
+		want    string
+		wantErr string
+	}{
+		{"named profile", "faith", "faith: jamaica", ""},
+		{"empty selects default", "", "local: jamaica in en-JM on 2026-10-19", ""},
+		{"unknown profile", "sports", "", `unknown instruction profile "sports"`},
+		{"missing key", "missing", "", `rendering profile "missing"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := in.Render(tt.profile, "jamaica", now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Render = %q, %v, want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package transformer
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// Schema derives a model response schema from a Go value's type. Struct fields
+// follow their json tags; fields tagged `schema:"-"` are left out so the model
+// is never asked to fill bookkeeping fields.
+func Schema(v any) *genai.Schema {
+	return schemaOf(reflect.TypeOf(v))
+}
+
+func schemaOf(t reflect.Type) *genai.Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &genai.Schema{Type: genai.TypeString}
+	case reflect.Bool:
+		return &genai.Schema{Type: genai.TypeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &genai.Schema{Type: genai.TypeInteger}
+	case reflect.Float32, reflect.Float64:
+		return &genai.Schema{Type: genai.TypeNumber}
+	case reflect.Slice, reflect.Array:
+		return &genai.Schema{Type: genai.TypeArray, Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &genai.Schema{Type: genai.TypeObject}
+	case reflect.Struct:
+		s := &genai.Schema{Type: genai.TypeObject, Properties: map[string]*genai.Schema{}}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() || f.Tag.Get("schema") == "-" {
+				continue
+			}
+
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+
+			s.Properties[name] = schemaOf(f.Type)
+			if !strings.Contains(opts, "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	}
+
+	return &genai.Schema{Type: genai.TypeString}
+}
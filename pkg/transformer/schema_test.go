@@ -0,0 +1,70 @@
+package transformer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+type schemaItem struct {
+	Title    string            `json:"title"`
+	Score    int               `json:"score"`
+	Weight   float64           `json:"weight,omitempty"`
+	Draft    *bool             `json:"draft"`
+	Tags     []string          `json:"tags"`
+	Extra    map[string]string `json:"extra,omitempty"`
+	Untagged string
+	Hidden   string `json:"-"`
+	Internal string `json:"internal" schema:"-"`
+	private  string
+}
+
+type schemaWrapper struct {
+	Items []schemaItem `json:"items"`
+}
+
+func TestSchema(t *testing.T) {
+	items := Schema(schemaWrapper{}).Properties["items"]
+	if items == nil || items.Type != genai.TypeArray || items.Items == nil {
+		t.Fatalf("items schema = %+v, want an array of objects", items)
+	}
+	item := items.Items
+
+	tests := []struct {
+		field    string
+		want     genai.Type
+		required bool
+	}{
+		{"title", genai.TypeString, true},
+		{"score", genai.TypeInteger, true},
+		{"weight", genai.TypeNumber, false},
+		{"draft", genai.TypeBoolean, true},
+		{"tags", genai.TypeArray, true},
+		{"extra", genai.TypeObject, false},
+		{"Untagged", genai.TypeString, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			prop, ok := item.Properties[tt.field]
+			if !ok {
+				t.Fatalf("no property %s", tt.field)
+			}
+			if prop.Type != tt.want {
+				t.Errorf("%s type = %v, want %v", tt.field, prop.Type, tt.want)
+			}
+			if got := slices.Contains(item.Required, tt.field); got != tt.required {
+				t.Errorf("%s required = %v, want %v", tt.field, got, tt.required)
+			}
+		})
+	}
+
+	for _, skipped := range []string{"Hidden", "-", "internal", "private"} {
+		if _, ok := item.Properties[skipped]; ok {
+			t.Errorf("property %s should be left out", skipped)
+		}
+	}
+	if got := item.Properties["tags"].Items; got == nil || got.Type != genai.TypeString {
+		t.Errorf("tags items = %+v, want strings", got)
+	}
+}
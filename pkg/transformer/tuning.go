@@ -0,0 +1,144 @@
+package transformer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Overrides replaces individual model parameters; nil fields keep the default
+type Overrides struct {
+	Temperature      *float32 `json:"temperature,omitempty"`
+	TopK             *int32   `json:"top_k,omitempty"`
+	TopP             *float32 `json:"top_p,omitempty"`
+	MaxOutputTokens  *int32   `json:"max_output_tokens,omitempty"`
+	ResponseMIMEType *string  `json:"response_mime_type,omitempty"`
+}
+
+// Apply returns p with every set override applied
+func (o Overrides) Apply(p Parameters) Parameters {
+	if o.Temperature != nil {
+		p.Temperature = *o.Temperature
+	}
+	if o.TopK != nil {
+		p.TopK = *o.TopK
+	}
+	if o.TopP != nil {
+		p.TopP = *o.TopP
+	}
+	if o.MaxOutputTokens != nil {
+		p.MaxOutputTokens = *o.MaxOutputTokens
+	}
+	if o.ResponseMIMEType != nil {
+		p.ResponseMIMEType = *o.ResponseMIMEType
+	}
+	return p
+}
+
+// Returns one message per out-of-range field, prefixed with path
+func (o Overrides) validate(path string) []string {
+	var problems []string
+	if o.Temperature != nil && (*o.Temperature < 0 || *o.Temperature > 2) {
+		problems = append(problems, fmt.Sprintf("%s.temperature: must be between 0 and 2, got %v", path, *o.Temperature))
+	}
+	if o.TopK != nil && *o.TopK < 1 {
+		problems = append(problems, fmt.Sprintf("%s.top_k: must be at least 1, got %d", path, *o.TopK))
+	}
+	if o.TopP != nil && (*o.TopP <= 0 || *o.TopP > 1) {
+		problems = append(problems, fmt.Sprintf("%s.top_p: must be in (0, 1], got %v", path, *o.TopP))
+	}
+	if o.MaxOutputTokens != nil && *o.MaxOutputTokens < 1 {
+		problems = append(problems, fmt.Sprintf("%s.max_output_tokens: must be at least 1, got %d", path, *o.MaxOutputTokens))
+	}
+	if o.ResponseMIMEType != nil && *o.ResponseMIMEType != "text/plain" && *o.ResponseMIMEType != "application/json" {
+		problems = append(problems, fmt.Sprintf("%s.response_mime_type: must be text/plain or application/json, got %q", path, *o.ResponseMIMEType))
+	}
+	return problems
+}
+
+// Pipeline stages that accept parameter overrides
+const (
+	StageReport = "report"
+	StageRepair = "repair"
+)
+
+// Tuning layers configured overrides on top of the built-in parameter map:
+// built-in defaults for the model, then per-model overrides, then per-stage
+type Tuning struct {
+	Models map[string]Overrides `json:"models"`
+	Stages map[string]Overrides `json:"stages"`
+}
+
+// Validate checks every override and reports all problems at once. Model
+// overrides must name a model in chain, or a built-in model when chain is empty.
+func (t *Tuning) Validate(chain []string) error {
+	if t == nil {
+		return nil
+	}
+
+	known := func(name string) bool {
+		if len(chain) == 0 {
+			_, ok := paramMap[name]
+			return ok
+		}
+		return slices.Contains(chain, name)
+	}
+
+	var problems []string
+	for _, name := range sortedKeys(t.Models) {
+		path := "parameters.models." + name
+		if !known(name) {
+			problems = append(problems, fmt.Sprintf("%s: model %q is not in the configured models", path, name))
+		}
+		problems = append(problems, t.Models[name].validate(path)...)
+	}
+	for _, name := range sortedKeys(t.Stages) {
+		path := "parameters.stages." + name
+		if name != StageReport && name != StageRepair {
+			problems = append(problems, fmt.Sprintf("%s: unknown stage %q, want %s or %s", path, name, StageReport, StageRepair))
+		}
+		problems = append(problems, t.Stages[name].validate(path)...)
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// Resolve returns the parameters for base during stage, and a short
+// description of which layers were applied
+func (t *Tuning) Resolve(base, stage string) (Parameters, string) {
+	params, builtin := paramMap[base]
+	applied := []string{"builtin:" + base}
+	if !builtin {
+		params = defaultTopK40
+		applied = []string{"builtin:default"}
+		log.Printf("⚠️ No built-in parameters for model %s, using defaults", base)
+	}
+
+	if t != nil {
+		if o, ok := t.Models[base]; ok {
+			params = o.Apply(params)
+			applied = append(applied, "model:"+base)
+		}
+		if o, ok := t.Stages[stage]; ok {
+			params = o.Apply(params)
+			applied = append(applied, "stage:"+stage)
+		}
+	}
+
+	return params, strings.Join(applied, "+")
+}
+
+func sortedKeys(m map[string]Overrides) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,91 @@
+package transformer
+
+import (
+	"strings"
+	"testing"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestTuningResolve(t *testing.T) {
+	tuning := &Tuning{
+		Models: map[string]Overrides{"gemini-2.0-flash": {TopK: ptr[int32](10)}},
+		Stages: map[string]Overrides{StageRepair: {Temperature: ptr[float32](0.2)}},
+	}
+
+	tests := []struct {
+		name        string
+		tuning      *Tuning
+		base, stage string
+		wantTopK    int32
+		wantTemp    float32
+		wantApplied string
+	}{
+		{"nil tuning", nil, "gemini-2.0-flash", StageReport, 40, 1, "builtin:gemini-2.0-flash"},
+		{"model override", tuning, "gemini-2.0-flash", StageReport, 10, 1, "builtin:gemini-2.0-flash+model:gemini-2.0-flash"},
+		{"model and stage", tuning, "gemini-2.0-flash", StageRepair, 10, 0.2, "builtin:gemini-2.0-flash+model:gemini-2.0-flash+stage:repair"},
+		{"stage only", tuning, "gemini-1.5-pro", StageRepair, 40, 0.2, "builtin:gemini-1.5-pro+stage:repair"},
+		{"unknown model", tuning, "gemini-9", StageReport, 40, 1, "builtin:default"},
+		{"unknown stage", tuning, "gemini-2.0-flash", "summarize", 10, 1, "builtin:gemini-2.0-flash+model:gemini-2.0-flash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, applied := tt.tuning.Resolve(tt.base, tt.stage)
+			if params.TopK != tt.wantTopK || params.Temperature != tt.wantTemp {
+				t.Errorf("Resolve = top_k %d temperature %v, want %d %v", params.TopK, params.Temperature, tt.wantTopK, tt.wantTemp)
+			}
+			if applied != tt.wantApplied {
+				t.Errorf("applied = %q, want %q", applied, tt.wantApplied)
+			}
+		})
+	}
+}
+
+func TestTuningValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tuning  *Tuning
+		chain   []string
+		wantErr []string
+	}{
+		{"nil", nil, nil, nil},
+		{"valid", &Tuning{
+			Models: map[string]Overrides{"gemini-2.0-flash": {TopP: ptr[float32](0.5)}},
+			Stages: map[string]Overrides{StageReport: {}, StageRepair: {}},
+		}, []string{"gemini-2.0-flash"}, nil},
+		{"unknown stage", &Tuning{
+			Stages: map[string]Overrides{"summarize": {}},
+		}, nil, []string{`parameters.stages.summarize: unknown stage "summarize"`}},
+		{"model outside chain", &Tuning{
+			Models: map[string]Overrides{"gemini-1.5-pro": {}},
+		}, []string{"gemini-2.0-flash"}, []string{`parameters.models.gemini-1.5-pro: model "gemini-1.5-pro" is not in the configured models`}},
+		{"built-in model without chain", &Tuning{
+			Models: map[string]Overrides{"gemini-1.5-pro": {}},
+		}, nil, nil},
+		{"unknown model without chain", &Tuning{
+			Models: map[string]Overrides{"gemini-9": {}},
+		}, nil, []string{"parameters.models.gemini-9: model"}},
+		{"out of range", &Tuning{
+			Stages: map[string]Overrides{StageRepair: {Temperature: ptr[float32](3), TopK: ptr[int32](0)}},
+		}, nil, []string{"parameters.stages.repair.temperature", "parameters.stages.repair.top_k"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tuning.Validate(tt.chain)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate = nil, want %q", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate = %q, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
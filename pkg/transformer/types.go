@@ -20,6 +20,17 @@ type Parameters struct {
 	SystemInstruction *genai.Content
 }
 
+// JSONMode reports whether the parameters request constrained JSON output
+func (p *Parameters) JSONMode() bool {
+	return p.ResponseMIMEType == "application/json"
+}
+
+// Instruction builds a fresh system instruction so sessions never share one
+// mutable genai.Content
+func Instruction(text string) *genai.Content {
+	return &genai.Content{Parts: []genai.Part{genai.Text(text)}}
+}
+
 // SetSystemInstructions sets the system instructions for the model
 func (p *Parameters) SetSystemInstructions(i **genai.Content) {
 	p.SystemInstruction = *i
@@ -41,25 +52,31 @@ func (c *Configuration) SetKey(k *API) {
 	c.Key = *k
 }
 
-var noContent = genai.Content{Parts: []genai.Part{genai.Text(" ")}}
-
-// Define common parameter sets
+// Define common parameter sets. They carry no system instruction; each
+// session gets its own from Instruction.
 var defaultTopK40 = Parameters{
-	Temperature:       1,
-	TopK:              40,
-	TopP:              0.95,
-	MaxOutputTokens:   8192,
-	ResponseMIMEType:  "text/plain",
-	SystemInstruction: &noContent,
+	Temperature:      1,
+	TopK:             40,
+	TopP:             0.95,
+	MaxOutputTokens:  8192,
+	ResponseMIMEType: "application/json",
 }
 
 var defaultTopK64 = Parameters{
-	Temperature:       1,
-	TopK:              64,
-	TopP:              0.95,
-	MaxOutputTokens:   8192,
-	ResponseMIMEType:  "text/plain",
-	SystemInstruction: &noContent,
+	Temperature:      1,
+	TopK:             64,
+	TopP:             0.95,
+	MaxOutputTokens:  8192,
+	ResponseMIMEType: "application/json",
+}
+
+// Experimental models without JSON mode fall back to plain text
+var plainTopK64 = Parameters{
+	Temperature:      1,
+	TopK:             64,
+	TopP:             0.95,
+	MaxOutputTokens:  8192,
+	ResponseMIMEType: "text/plain",
 }
 
 // Map of models to their corresponding parameter sets
@@ -68,17 +85,15 @@ var paramMap = map[string]Parameters{
 	"gemini-2.0-flash-exp":                defaultTopK40,
 	"gemini-2.0-flash-lite":               defaultTopK40,
 	"gemini-2.0-pro-exp-02-05":            defaultTopK64,
-	"gemini-2.0-flash-thinking-exp-01-21": {Temperature: 0.7, TopK: 64, TopP: 0.95, MaxOutputTokens: 8192, ResponseMIMEType: "text/plain", SystemInstruction: &noContent},
-	"learnlm-1.5-pro-experimental":        defaultTopK64,
+	"gemini-2.0-flash-thinking-exp-01-21": {Temperature: 0.7, TopK: 64, TopP: 0.95, MaxOutputTokens: 8192, ResponseMIMEType: "text/plain"},
+	"learnlm-1.5-pro-experimental":        plainTopK64,
 	"gemini-1.5-pro":                      defaultTopK40,
 	"gemini-1.5-flash":                    defaultTopK40,
 	"gemini-1.5-flash-8b":                 defaultTopK40,
 }
 
-// Parameters returns the parameters for an API key by matching api.Base or defaulting to defaultTopK40
+// Parameters returns the built-in parameters for an API key by matching api.Base or defaulting to defaultTopK40
 func (api *API) Parameters() Parameters {
-	if params, exists := paramMap[api.Base]; exists {
-		return params
-	}
-	return defaultTopK40 // Fallback default
+	params, _ := (*Tuning)(nil).Resolve(api.Base, "")
+	return params
 }
@@ -37,3 +37,42 @@ func PartsToString(parts []genai.Part) string {
 	}
 	return sb.String()
 }
+
+// ExtractJSON returns the first balanced JSON object or array found in input,
+// tolerating surrounding prose and code fences from models without JSON mode.
+func ExtractJSON(input string) (string, bool) {
+	start := strings.IndexAny(input, "{[")
+	if start < 0 {
+		return "", false
+	}
+
+	depth := 0
+	inString, escaped := false, false
+	for i := start; i < len(input); i++ {
+		c := input[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return input[start : i+1], true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,31 @@
+package transformer
+
+import "testing"
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"bare object", `{"a": 1}`, `{"a": 1}`, true},
+		{"bare array", `[1, [2]]`, `[1, [2]]`, true},
+		{"code fence", "```json\n{\"a\": 1}\n```", `{"a": 1}`, true},
+		{"prose around", `Here you go: {"a": {"b": 2}} Hope that helps {"c": 3}`, `{"a": {"b": 2}}`, true},
+		{"braces in strings", `{"a": "}{]["}`, `{"a": "}{]["}`, true},
+		{"escaped quote", `{"a": "say \"}\""}`, `{"a": "say \"}\""}`, true},
+		{"escaped backslash", `{"a": "c:\\"} trailing }`, `{"a": "c:\\"}`, true},
+		{"unbalanced", `{"a": [1, 2}`, "", false},
+		{"no json", `no reports today`, "", false},
+		{"empty", ``, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ExtractJSON(tt.in)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ExtractJSON(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}